@@ -0,0 +1,276 @@
+package ili948x
+
+import (
+	"io"
+	"math/bits"
+)
+
+// https://en.wikipedia.org/wiki/BMP_file_format
+const (
+	bmpFileHeaderSize = 14
+	bmpInfoHeaderSize = 40  // BITMAPINFOHEADER
+	bmpV4HeaderSize   = 108 // BITMAPV4HEADER
+	bmpV5HeaderSize   = 124 // BITMAPV5HEADER
+
+	bmpRGB            = 0 // BI_RGB
+	bmpBitFields      = 3 // BI_BITFIELDS
+	bmpAlphaBitFields = 6 // BI_ALPHABITFIELDS
+)
+
+// bmpChannel extracts one color channel from a 16 or 32 bit pixel.
+type bmpChannel struct {
+	mask  uint32
+	shift int
+	bits  int
+}
+
+func newBMPChannel(mask uint32) bmpChannel {
+	return bmpChannel{
+		mask:  mask,
+		shift: bits.TrailingZeros32(mask),
+		bits:  bits.OnesCount32(mask),
+	}
+}
+
+// value returns the channel scaled to 8 bits.
+func (c bmpChannel) value(px uint32) uint32 {
+	if c.bits == 0 {
+		return 0
+	}
+	v := (px & c.mask) >> c.shift
+	if c.bits >= 8 {
+		return v >> (c.bits - 8)
+	}
+	return v * 0xff / (1<<c.bits - 1)
+}
+
+// bmpReader reads a BMP stream, keeping track of the file offset.
+type bmpReader struct {
+	r    io.Reader
+	offs uint32
+	buf  [bmpV5HeaderSize]uint8
+}
+
+// read fills b, a truncated file is reported as ErrFormat.
+func (br *bmpReader) read(b []uint8) error {
+	n, err := io.ReadFull(br.r, b)
+	br.offs += uint32(n)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return &Error{Op: "DrawBMP", Kind: ErrFormat, Err: io.ErrUnexpectedEOF}
+	}
+	return err
+}
+
+// skip discards bytes up to the given file offset.
+func (br *bmpReader) skip(offs uint32) error {
+	for br.offs < offs {
+		n := offs - br.offs
+		if n > uint32(len(br.buf)) {
+			n = uint32(len(br.buf))
+		}
+		if err := br.read(br.buf[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DrawBMP decodes a BMP image and draws it with its top-left corner at x, y.
+// BITMAPINFOHEADER, V4 and V5 files are supported with 1, 4 and 8 bit palettes,
+// 16 bit (555, or 565 and other bitfields), 24 bit and 32 bit pixels, in
+// bottom-up or top-down row order. Alpha is ignored. The image is clipped to
+// the display and reading stops once the remaining rows are off-screen.
+// Malformed and truncated files return ErrFormat.
+func (disp *Ili948x) DrawBMP(x, y int16, r io.Reader) error {
+	const op = "DrawBMP"
+	br := &bmpReader{r: r}
+
+	// file header
+	hdr := br.buf[:bmpFileHeaderSize]
+	if err := br.read(hdr); err != nil {
+		return err
+	}
+	if hdr[0] != 'B' || hdr[1] != 'M' {
+		return &Error{Op: op, Kind: ErrFormat}
+	}
+	pixelOffs := le32(hdr[10:])
+
+	// info header
+	if err := br.read(br.buf[:4]); err != nil {
+		return err
+	}
+	hdrSize := le32(br.buf[:])
+	switch hdrSize {
+	case bmpInfoHeaderSize, 52, 56, bmpV4HeaderSize, bmpV5HeaderSize: // 52, 56: V2 / V3 (masks only)
+	default:
+		return &Error{Op: op, Kind: ErrNotSupported}
+	}
+	hdr = br.buf[:hdrSize]
+	if err := br.read(hdr[4:]); err != nil {
+		return err
+	}
+	width := int32(le32(hdr[4:]))
+	height := int32(le32(hdr[8:]))
+	bpp := int(le16(hdr[14:]))
+	compression := le32(hdr[16:])
+	colorsUsed := le32(hdr[32:])
+
+	topDown := height < 0
+	if topDown {
+		height = -height
+	}
+	if width <= 0 || height == 0 || width > 0x7fff || height > 0x7fff {
+		return &Error{Op: op, Kind: ErrFormat}
+	}
+
+	// pixel layout
+	var red, green, blue bmpChannel
+	var palette []uint32
+	switch {
+	case compression == bmpRGB && (bpp == 1 || bpp == 4 || bpp == 8):
+		n := colorsUsed
+		if n == 0 || n > 1<<bpp {
+			n = 1 << bpp
+		}
+		palette = make([]uint32, n)
+		entry := br.buf[:4]
+		for i := range palette {
+			if err := br.read(entry); err != nil {
+				return err
+			}
+			palette[i] = uint32(entry[2])<<16 | uint32(entry[1])<<8 | uint32(entry[0])
+		}
+	case compression == bmpRGB && bpp == 16:
+		red, green, blue = newBMPChannel(0x7c00), newBMPChannel(0x03e0), newBMPChannel(0x001f)
+	case compression == bmpRGB && (bpp == 24 || bpp == 32):
+		red, green, blue = newBMPChannel(0xff0000), newBMPChannel(0x00ff00), newBMPChannel(0x0000ff)
+	case (compression == bmpBitFields || compression == bmpAlphaBitFields) && (bpp == 16 || bpp == 32):
+		masks := hdr[40:]
+		if hdrSize == bmpInfoHeaderSize { // masks follow the header
+			masks = br.buf[bmpInfoHeaderSize : bmpInfoHeaderSize+12]
+			if err := br.read(masks); err != nil {
+				return err
+			}
+		}
+		red, green, blue = newBMPChannel(le32(masks[0:])), newBMPChannel(le32(masks[4:])), newBMPChannel(le32(masks[8:]))
+	default:
+		return &Error{Op: op, Kind: ErrNotSupported}
+	}
+
+	if err := br.skip(pixelOffs); err != nil {
+		return err
+	}
+
+	// visible columns
+	_, h := disp.Size()
+	cx, _, cw, _, ok := disp.clip(int32(x), 0, width, int32(h))
+	if !ok {
+		return nil
+	}
+	left := int(int32(cx) - int32(x))
+
+	stride := (int(width)*bpp + 31) / 32 * 4
+	src := make([]uint8, stride)
+	row := make([]uint32, cw)
+	for i := int32(0); i < height; i++ {
+		// display row of this source row
+		ry := int32(y) + i
+		if !topDown {
+			ry = int32(y) + height - 1 - i
+		}
+		if (topDown && ry >= int32(h)) || (!topDown && ry < 0) {
+			break // remaining rows are off-screen
+		}
+
+		if err := br.read(src); err != nil {
+			return err
+		}
+		if ry < 0 || ry >= int32(h) {
+			continue
+		}
+
+		for j := range row {
+			px := left + j
+			var c uint32
+			switch bpp {
+			case 1, 4, 8:
+				bit := px * bpp
+				idx := int(src[bit/8]>>(8-bpp-bit%8)) & (1<<bpp - 1)
+				if idx < len(palette) {
+					c = palette[idx]
+				}
+			case 16:
+				v := uint32(le16(src[px*2:]))
+				c = red.value(v)<<16 | green.value(v)<<8 | blue.value(v)
+			case 24:
+				c = uint32(src[px*3+2])<<16 | uint32(src[px*3+1])<<8 | uint32(src[px*3])
+			case 32:
+				v := le32(src[px*4:])
+				c = red.value(v)<<16 | green.value(v)<<8 | blue.value(v)
+			}
+			row[j] = c
+		}
+		if err := disp.writeWindow(cx, uint16(ry), cw, 1, row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Screenshot reads back the frame memory as seen in the current rotation and
+// writes it to w as a 24 bit top-down BMP image. The scroll offset is not
+// applied. The transport must implement ReadTransport.
+func (disp *Ili948x) Screenshot(w io.Writer) error {
+	width, height := disp.Size()
+	stride := (int(width)*3 + 3) &^ 3
+
+	hdr := make([]uint8, bmpFileHeaderSize+bmpInfoHeaderSize)
+	hdr[0], hdr[1] = 'B', 'M'
+	putLE32(hdr[2:], uint32(len(hdr)+stride*int(height))) // file size
+	putLE32(hdr[10:], uint32(len(hdr)))                   // pixel offset
+	info := hdr[bmpFileHeaderSize:]
+	putLE32(info[0:], bmpInfoHeaderSize)
+	putLE32(info[4:], uint32(width))
+	putLE32(info[8:], uint32(-int32(height))) // top-down
+	putLE16(info[12:], 1)                     // planes
+	putLE16(info[14:], 24)                    // bits per pixel
+	putLE32(info[20:], uint32(stride*int(height)))
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+
+	colors := make([]uint32, width)
+	line := make([]uint8, stride)
+	for y := int16(0); y < height; y++ {
+		if err := disp.ReadRectangle(0, y, width, 1, colors); err != nil {
+			return err
+		}
+		for i, c := range colors {
+			line[i*3] = uint8(c)
+			line[i*3+1] = uint8(c >> 8)
+			line[i*3+2] = uint8(c >> 16)
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func le16(b []uint8) uint16 {
+	return uint16(b[0]) | uint16(b[1])<<8
+}
+
+func le32(b []uint8) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+func putLE16(b []uint8, v uint16) {
+	b[0], b[1] = uint8(v), uint8(v>>8)
+}
+
+func putLE32(b []uint8, v uint32) {
+	b[0], b[1], b[2], b[3] = uint8(v), uint8(v>>8), uint8(v>>16), uint8(v>>24)
+}
@@ -0,0 +1,344 @@
+package ili948x_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/sim"
+)
+
+// testBMP describes a BMP file to build: pixel returns the stored value of a
+// pixel (a palette index or a packed pixel) and color the color it shows.
+type testBMP struct {
+	width, height int
+	bpp           int
+	hdrSize       int // 40, 108 or 124
+	compression   uint32
+	masks         []uint32 // red, green, blue bitfields
+	palette       []uint32 // 0xrrggbb
+	topDown       bool
+	pixel         func(x, y int) uint32
+	color         func(x, y int) uint32
+}
+
+// encode returns the BMP file.
+func (b *testBMP) encode() []uint8 {
+	var info bytes.Buffer
+	height := int32(b.height)
+	if b.topDown {
+		height = -height
+	}
+	le := binary.LittleEndian
+	binary.Write(&info, le, uint32(b.hdrSize))
+	binary.Write(&info, le, int32(b.width))
+	binary.Write(&info, le, height)
+	binary.Write(&info, le, uint16(1)) // planes
+	binary.Write(&info, le, uint16(b.bpp))
+	binary.Write(&info, le, b.compression)
+	binary.Write(&info, le, make([]uint8, 12)) // image size, resolution
+	binary.Write(&info, le, uint32(len(b.palette)))
+	binary.Write(&info, le, uint32(0)) // important colors
+	if b.hdrSize > 40 {
+		hdr := make([]uint8, b.hdrSize-40)
+		for i, m := range b.masks {
+			le.PutUint32(hdr[i*4:], m)
+		}
+		info.Write(hdr)
+	} else {
+		for _, m := range b.masks {
+			binary.Write(&info, le, m)
+		}
+	}
+	for _, c := range b.palette {
+		binary.Write(&info, le, c) // blue, green, red, reserved
+	}
+
+	stride := (b.width*b.bpp + 31) / 32 * 4
+	pixels := make([]uint8, stride*b.height)
+	for y := 0; y < b.height; y++ {
+		row := pixels[y*stride:]
+		if !b.topDown {
+			row = pixels[(b.height-1-y)*stride:]
+		}
+		for x := 0; x < b.width; x++ {
+			v := b.pixel(x, y)
+			switch b.bpp {
+			case 1, 4, 8:
+				bit := x * b.bpp
+				row[bit/8] |= uint8(v << (8 - b.bpp - bit%8))
+			case 16:
+				le.PutUint16(row[x*2:], uint16(v))
+			case 24:
+				row[x*3], row[x*3+1], row[x*3+2] = uint8(v), uint8(v>>8), uint8(v>>16)
+			case 32:
+				le.PutUint32(row[x*4:], v)
+			}
+		}
+	}
+
+	// gap between the headers and the pixels
+	offs := 14 + info.Len() + 2
+	var file bytes.Buffer
+	file.WriteString("BM")
+	binary.Write(&file, le, uint32(offs+len(pixels)))
+	binary.Write(&file, le, uint32(0))
+	binary.Write(&file, le, uint32(offs))
+	file.Write(info.Bytes())
+	file.Write([]uint8{0, 0})
+	file.Write(pixels)
+	return file.Bytes()
+}
+
+// scale returns a bits wide channel value scaled to 8 bits.
+func scale(v uint32, bits int) uint32 {
+	return v * 0xff / (1<<bits - 1)
+}
+
+// rgb24 is the color of the 24 and 32 bit test images.
+func rgb24(x, y int) uint32 {
+	return uint32(x*60)<<16 | uint32(y*80)<<8 | uint32(0xff-x*30-y*20)
+}
+
+var testPalette = []uint32{0x000000, 0xffffff, 0xff0000, 0x00ff00, 0x0000ff, 0xffff00, 0x00ffff, 0x808080, 0xc04000}
+
+var bmpTests = []struct {
+	name string
+	bmp  testBMP
+}{
+	{"1bpp", testBMP{
+		width: 11, height: 3, bpp: 1, hdrSize: 40,
+		palette: []uint32{0x204080, 0xf0e0d0},
+		pixel:   func(x, y int) uint32 { return uint32(x+y) % 2 },
+		color:   func(x, y int) uint32 { return []uint32{0x204080, 0xf0e0d0}[(x+y)%2] },
+	}},
+	{"4bpp", testBMP{
+		width: 5, height: 4, bpp: 4, hdrSize: 40,
+		palette: testPalette,
+		pixel:   func(x, y int) uint32 { return uint32(x+2*y) % 9 },
+		color:   func(x, y int) uint32 { return testPalette[(x+2*y)%9] },
+	}},
+	{"8bpp", testBMP{
+		width: 3, height: 5, bpp: 8, hdrSize: 40,
+		palette: testPalette,
+		pixel:   func(x, y int) uint32 { return uint32(x*3+y) % 9 },
+		color:   func(x, y int) uint32 { return testPalette[(x*3+y)%9] },
+	}},
+	{"16bpp 555", testBMP{
+		width: 5, height: 3, bpp: 16, hdrSize: 40,
+		pixel: func(x, y int) uint32 { return uint32(x*7)<<10 | uint32(y*15)<<5 | uint32(31-x) },
+		color: func(x, y int) uint32 {
+			return scale(uint32(x*7), 5)<<16 | scale(uint32(y*15), 5)<<8 | scale(uint32(31-x), 5)
+		},
+	}},
+	{"16bpp 565 bitfields", testBMP{
+		width: 5, height: 3, bpp: 16, hdrSize: 40, compression: 3,
+		masks: []uint32{0xf800, 0x07e0, 0x001f},
+		pixel: func(x, y int) uint32 { return uint32(x*7)<<11 | uint32(y*30)<<5 | uint32(31-x) },
+		color: func(x, y int) uint32 {
+			return scale(uint32(x*7), 5)<<16 | scale(uint32(y*30), 6)<<8 | scale(uint32(31-x), 5)
+		},
+	}},
+	{"24bpp bottom-up", testBMP{
+		width: 5, height: 3, bpp: 24, hdrSize: 40,
+		pixel: rgb24, color: rgb24,
+	}},
+	{"24bpp top-down", testBMP{
+		width: 5, height: 3, bpp: 24, hdrSize: 124, topDown: true,
+		pixel: rgb24, color: rgb24,
+	}},
+	{"32bpp", testBMP{
+		width: 4, height: 3, bpp: 32, hdrSize: 40,
+		pixel: func(x, y int) uint32 { return 0xff000000 | rgb24(x, y) },
+		color: rgb24,
+	}},
+	{"32bpp bitfields v4", testBMP{
+		width: 4, height: 3, bpp: 32, hdrSize: 108, compression: 3, topDown: true,
+		masks: []uint32{0x000000ff, 0x0000ff00, 0x00ff0000}, // rgba byte order
+		pixel: func(x, y int) uint32 {
+			c := rgb24(x, y)
+			return c>>16 | c&0xff00 | (c&0xff)<<16
+		},
+		color: rgb24,
+	}},
+}
+
+// simDisplay returns a driver on a simulated controller and a function
+// returning the frame memory content of a pixel filled with a color.
+func simDisplay(t *testing.T) (*ili948x.Ili948x, *sim.Display, func(c uint32) uint32) {
+	t.Helper()
+	disp, d := newSimulated(t)
+	fill := func(c uint32) uint32 {
+		if err := disp.FillRectangle(319, 0, 1, 1, c); err != nil {
+			t.Fatal(err)
+		}
+		return d.Pixel(319, 0)
+	}
+	return disp, d, fill
+}
+
+func TestDrawBMP(t *testing.T) {
+	disp, d, fill := simDisplay(t)
+	const x0, y0 = 10, 20
+	for _, tt := range bmpTests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &tt.bmp
+			if err := disp.FillScreen(0x123456); err != nil {
+				t.Fatal(err)
+			}
+			if err := disp.DrawBMP(x0, y0, bytes.NewReader(b.encode())); err != nil {
+				t.Fatal(err)
+			}
+			bg := fill(0x123456)
+			// one pixel border to catch writes outside of the image
+			for y := -1; y <= b.height; y++ {
+				for x := -1; x <= b.width; x++ {
+					got := d.Pixel(x0+x, y0+y)
+					want := bg
+					if x >= 0 && y >= 0 && x < b.width && y < b.height {
+						want = fill(b.color(x, y))
+					}
+					if got != want {
+						t.Errorf("pixel (%d, %d): got %05x, want %05x", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDrawBMPClipped(t *testing.T) {
+	disp, d, fill := simDisplay(t)
+	b := &bmpTests[5].bmp // 24bpp bottom-up
+	for _, pos := range []struct{ x, y int16 }{{-2, -1}, {317, 478}} {
+		if err := disp.DrawBMP(pos.x, pos.y, bytes.NewReader(b.encode())); err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < b.height; y++ {
+			for x := 0; x < b.width; x++ {
+				dx, dy := int(pos.x)+x, int(pos.y)+y
+				if dx < 0 || dy < 0 || dx >= sim.Width-1 || dy >= sim.Height {
+					continue // off-screen, or the fill probe
+				}
+				if got, want := d.Pixel(dx, dy), fill(b.color(x, y)); got != want {
+					t.Errorf("at %v: pixel (%d, %d): got %05x, want %05x", pos, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestDrawBMPErrors(t *testing.T) {
+	disp, _, _ := simDisplay(t)
+	file := bmpTests[1].bmp.encode() // 4bpp
+	const paletteEnd = 14 + 40 + 9*4
+
+	badMagic := append([]uint8(nil), file...)
+	badMagic[0] = 'X'
+	rle := append([]uint8(nil), file...)
+	rle[14+16] = 2 // BI_RLE4
+
+	for _, tt := range []struct {
+		name string
+		data []uint8
+		kind error
+	}{
+		{"empty", nil, ili948x.ErrFormat},
+		{"file header", file[:10], ili948x.ErrFormat},
+		{"info header", file[:30], ili948x.ErrFormat},
+		{"palette", file[:paletteEnd-2], ili948x.ErrFormat},
+		{"pixel offset", file[:paletteEnd+1], ili948x.ErrFormat},
+		{"pixels", file[:len(file)-1], ili948x.ErrFormat},
+		{"magic", badMagic, ili948x.ErrFormat},
+		{"compression", rle, ili948x.ErrNotSupported},
+	} {
+		err := disp.DrawBMP(0, 0, bytes.NewReader(tt.data))
+		if !errors.Is(err, tt.kind) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.kind)
+		}
+	}
+
+	// reader errors are passed on
+	readErr := errors.New("read failure")
+	err := disp.DrawBMP(0, 0, io.MultiReader(bytes.NewReader(file[:20]), &errReader{readErr}))
+	if !errors.Is(err, readErr) {
+		t.Errorf("got %v, want %v", err, readErr)
+	}
+}
+
+// errReader is an io.Reader failing with err.
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]uint8) (int, error) {
+	return 0, r.err
+}
+
+func TestScreenshotRoundTrip(t *testing.T) {
+	for _, rot := range []ili948x.Rotation{ili948x.Rot_0, ili948x.Rot_90, ili948x.Rot_180, ili948x.Rot_270} {
+		disp, d := newSimulated(t)
+		if err := disp.SetRotation(rot); err != nil {
+			t.Fatal(err)
+		}
+		// colors the 18 bit frame memory holds exactly
+		if err := disp.FillScreen(0x2040fc); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.FillRectangle(5, 7, 30, 20, 0xfc8004); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.DrawText(40, 10, "shot", ili948x.Font7x13, 0xfcfcfc, 0x000000); err != nil {
+			t.Fatal(err)
+		}
+		var grad []uint8
+		for i := 0; i < 64*8; i++ {
+			grad = append(grad, uint8(i<<2), uint8(i>>6<<5), uint8(0xfc-i%64<<2))
+		}
+		if err := disp.DisplayBitmap(100, 50, 64, 8, 24, bytes.NewReader(grad)); err != nil {
+			t.Fatal(err)
+		}
+
+		// ReadRectangle returns what was drawn
+		got := make([]uint32, 64*8)
+		if err := disp.ReadRectangle(100, 50, 64, 8, got); err != nil {
+			t.Fatal(err)
+		}
+		for i, c := range got {
+			p := grad[i*3:]
+			if want := uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16; c != want {
+				t.Fatalf("%v: pixel %d: read %#06x, want %#06x", rot, i, c, want)
+			}
+		}
+
+		var shot bytes.Buffer
+		if err := disp.Screenshot(&shot); err != nil {
+			t.Fatal(err)
+		}
+		w, h := disp.Size()
+		b := shot.Bytes()
+		le := binary.LittleEndian
+		if b[0] != 'B' || b[1] != 'M' || le.Uint32(b[2:]) != uint32(len(b)) ||
+			int32(le.Uint32(b[18:])) != int32(w) || int32(le.Uint32(b[22:])) != -int32(h) ||
+			le.Uint16(b[28:]) != 24 {
+			t.Fatalf("%v: bad header % x", rot, b[:54])
+		}
+		if len(b) != 54+int(w)*int(h)*3 {
+			t.Fatalf("%v: got %d bytes", rot, len(b))
+		}
+
+		// drawing the screenshot on another display gives the same frame
+		disp2, d2 := newSimulated(t)
+		if err := disp2.SetRotation(rot); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp2.DrawBMP(0, 0, &shot); err != nil {
+			t.Fatal(err)
+		}
+		checkImage(t, fmt.Sprint("rotation ", rot), d2.Image(), d.Image())
+	}
+}
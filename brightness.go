@@ -0,0 +1,121 @@
+package ili948x
+
+import (
+	"errors"
+	"time"
+)
+
+// CMD_WRCTRLD bits
+const (
+	ctrlBCTRL = 0x20 // brightness control block on
+	ctrlDD    = 0x08 // display dimming on
+	ctrlBL    = 0x04 // backlight control on
+)
+
+// fadeStep is the interval between brightness updates of a fade.
+const fadeStep = time.Millisecond * 20
+
+// bctrlSupport tells whether the backlight is driven by the controller's
+// brightness control block.
+type bctrlSupport uint8
+
+const (
+	bctrlUnknown bctrlSupport = iota // not yet detected
+	bctrlYes
+	bctrlNo
+)
+
+// WithBacklightControl tells whether the panel's backlight is driven by the
+// controller's CABC_PWM output, so SetBrightness dims it with CMD_WRDISBV.
+// Without it SetBrightness detects the brightness control block by reading
+// back CMD_RDCTRLD, which needs a ReadTransport.
+func WithBacklightControl(on bool) Option {
+	return func(disp *Ili948x) {
+		disp.bctrl = bctrlNo
+		if on {
+			disp.bctrl = bctrlYes
+		}
+	}
+}
+
+// GetBrightness returns the last brightness set.
+func (disp *Ili948x) GetBrightness() uint8 {
+	return disp.brightness
+}
+
+// SetBrightness sets the backlight brightness from 0 (off) to 255. A
+// DimmablePin backlight, e.g. a PWM channel, is driven at the level.
+// Otherwise the level is written to the controller with CMD_WRDISBV for
+// panels whose backlight is driven by its CABC_PWM output (see
+// WithBacklightControl), and a digital backlight pin is switched on for any
+// level above 0.
+func (disp *Ili948x) SetBrightness(level uint8) error {
+	if bl, ok := disp.bl.(DimmablePin); ok {
+		bl.SetLevel(level)
+		disp.brightness = level
+		return nil
+	}
+
+	on, err := disp.enableBCTRL()
+	if err != nil {
+		return err
+	}
+	if on {
+		if err := disp.writeCmd(CMD_WRDISBV, level); err != nil {
+			return err
+		}
+	}
+	if disp.bl != nil {
+		disp.bl.Set(level > 0)
+	}
+	disp.brightness = level
+	return nil
+}
+
+// enableBCTRL turns the brightness control block on with CMD_WRCTRLD once
+// after reset, detecting it first if needed. It reports whether the block
+// is in use.
+func (disp *Ili948x) enableBCTRL() (bool, error) {
+	if disp.bctrl == bctrlNo {
+		return false, nil
+	}
+	if disp.bctrlOn {
+		return true, nil
+	}
+	if err := disp.writeCmd(CMD_WRCTRLD, ctrlBCTRL|ctrlDD|ctrlBL); err != nil {
+		return false, err
+	}
+	if disp.bctrl == bctrlUnknown {
+		// controllers without the block do not keep the register
+		var ctrl [1]uint8
+		err := disp.readCmd(CMD_RDCTRLD, ctrl[:])
+		if errors.Is(err, ErrNotSupported) || (err == nil && ctrl[0]&ctrlBCTRL == 0) {
+			disp.bctrl = bctrlNo
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		disp.bctrl = bctrlYes
+	}
+	disp.bctrlOn = true
+	return true, nil
+}
+
+// FadeBrightness changes the brightness linearly to level over the given
+// duration, returning when the fade is done. With the controller's brightness
+// control block each step is a CMD_WRDISBV write on the bus, which must not
+// overlap drawing, so only a fade of a DimmablePin backlight may run in a
+// goroutine in the background, as long as the brightness is not read or set
+// meanwhile.
+func (disp *Ili948x) FadeBrightness(level uint8, duration time.Duration) error {
+	from := int(disp.brightness)
+	steps := int(duration / fadeStep)
+	for i := 1; i < steps; i++ {
+		if err := disp.SetBrightness(uint8(from + (int(level)-from)*i/steps)); err != nil {
+			return err
+		}
+		time.Sleep(fadeStep)
+	}
+	return disp.SetBrightness(level)
+}
@@ -0,0 +1,134 @@
+package ili948x_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/trace"
+)
+
+func TestSetBrightness(t *testing.T) {
+	wrctrld := trace.Op{Cmd: ili948x.CMD_WRCTRLD, Params: []uint8{0x2c}}
+	rdctrld := trace.Op{Cmd: ili948x.CMD_RDCTRLD}
+	wrdisbv := func(level uint8) trace.Op {
+		return trace.Op{Cmd: ili948x.CMD_WRDISBV, Params: []uint8{level}}
+	}
+
+	for _, tt := range []struct {
+		name    string
+		opts    []ili948x.Option
+		respond []uint8 // CMD_RDCTRLD response, dummy byte first
+		first   []trace.Op
+		pin     bool // digital backlight pin follows the level
+	}{
+		{"control", []ili948x.Option{ili948x.WithBacklightControl(true)}, nil,
+			[]trace.Op{wrctrld, wrdisbv(0x80)}, true},
+		{"no control", []ili948x.Option{ili948x.WithBacklightControl(false)}, nil,
+			nil, true},
+		{"detected", nil, []uint8{0x00, 0x2c},
+			[]trace.Op{wrctrld, rdctrld, wrdisbv(0x80)}, true},
+		{"not detected", nil, []uint8{0x00, 0x00},
+			[]trace.Op{wrctrld, rdctrld}, true},
+	} {
+		rec := trace.NewRecorder()
+		bl := ili948x.NewFakePin(false)
+		disp, err := ili948x.NewIli9488(rec, nil, rec.DC(), bl, nil, 0, 0, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec.Reset()
+		rec.Respond(tt.respond...)
+
+		if err := disp.SetBrightness(0x80); err != nil {
+			t.Fatal(err)
+		}
+		checkOps(t, rec, tt.first)
+		if !bl.Get() {
+			t.Errorf("%s: backlight pin low at level 0x80", tt.name)
+		}
+
+		// the control block is only turned on once
+		rec.Reset()
+		if err := disp.SetBrightness(0); err != nil {
+			t.Fatal(err)
+		}
+		var want []trace.Op
+		if len(tt.first) > 0 && tt.first[len(tt.first)-1].Cmd == ili948x.CMD_WRDISBV {
+			want = []trace.Op{wrdisbv(0)}
+		}
+		checkOps(t, rec, want)
+		if bl.Get() {
+			t.Errorf("%s: backlight pin high at level 0", tt.name)
+		}
+		if got := disp.GetBrightness(); got != 0 {
+			t.Errorf("%s: brightness %#x, want 0", tt.name, got)
+		}
+	}
+}
+
+func TestSetBrightnessAfterReset(t *testing.T) {
+	rec := trace.NewRecorder()
+	disp, err := ili948x.NewIli9488(rec, nil, rec.DC(), nil, nil, 0, 0, ili948x.WithBacklightControl(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.SetBrightness(0x80); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	if err := disp.SetBrightness(0x40); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_WRCTRLD, Params: []uint8{0x2c}},
+		{Cmd: ili948x.CMD_WRDISBV, Params: []uint8{0x40}},
+	})
+}
+
+func TestFadeBrightness(t *testing.T) {
+	rec := trace.NewRecorder()
+	disp, err := ili948x.NewIli9488(rec, nil, rec.DC(), nil, nil, 0, 0, ili948x.WithBacklightControl(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.SetBrightness(0xff); err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+
+	if err := disp.FadeBrightness(0, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	ops := rec.Ops()
+	if len(ops) < 2 {
+		t.Fatalf("got ops:\n%s", rec)
+	}
+	prev := 0x100
+	for _, op := range ops {
+		if op.Cmd != ili948x.CMD_WRDISBV || len(op.Params) != 1 || int(op.Params[0]) >= prev {
+			t.Fatalf("got ops:\n%swant decreasing CMD_WRDISBV writes only", rec)
+		}
+		prev = int(op.Params[0])
+	}
+	if prev != 0 {
+		t.Errorf("fade ended at %#x, want 0", prev)
+	}
+
+	// a dimmable pin is faded without bus traffic
+	pwm := &ili948x.FakePWMPin{}
+	disp, err = ili948x.NewIli9488(rec, nil, rec.DC(), pwm, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	if err := disp.FadeBrightness(0x20, 60*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Ops()) != 0 || pwm.Level != 0x20 {
+		t.Errorf("pwm level %#x, ops:\n%s", pwm.Level, rec)
+	}
+}
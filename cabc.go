@@ -0,0 +1,78 @@
+package ili948x
+
+// CABCMode is the content adaptive brightness control mode set with
+// CMD_WRCABC. The controller lowers the backlight on its CABC_PWM output
+// and boosts the image to compensate, depending on the content shown.
+type CABCMode uint8
+
+const (
+	CABCOff    CABCMode = 0x00 // off
+	CABCUI     CABCMode = 0x01 // user interface image
+	CABCStill  CABCMode = 0x02 // still picture
+	CABCMoving CABCMode = 0x03 // moving image
+)
+
+func (m CABCMode) String() string {
+	switch m {
+	case CABCOff:
+		return "off"
+	case CABCUI:
+		return "ui"
+	case CABCStill:
+		return "still"
+	case CABCMoving:
+		return "moving"
+	}
+	return "unknown"
+}
+
+// CABCState is the CABC configuration read back from the controller.
+type CABCState struct {
+	Mode          CABCMode // CMD_RDCABC
+	MinBrightness uint8    // CMD_RDCABCMB
+}
+
+// SetCABC selects the CABC mode. Enabling CABC also turns the brightness
+// control block on like SetBrightness, and fails with ErrNotSupported when the
+// backlight is not driven by it (see WithBacklightControl): the controller
+// would boost the image without dimming the backlight.
+func (disp *Ili948x) SetCABC(mode CABCMode) error {
+	const op = "SetCABC"
+	if mode > CABCMoving {
+		return &Error{Op: op, Kind: ErrNotSupported}
+	}
+	if mode != CABCOff {
+		on, err := disp.enableBCTRL()
+		if err != nil {
+			return err
+		}
+		if !on {
+			return &Error{Op: op, Kind: ErrNotSupported}
+		}
+	}
+	return disp.writeCmd(CMD_WRCABC, uint8(mode))
+}
+
+// SetCABCMinBrightness sets the lowest brightness, 0 to 255, CABC may dim
+// the backlight to.
+func (disp *Ili948x) SetCABCMinBrightness(level uint8) error {
+	return disp.writeCmd(CMD_WRCABCMB, level)
+}
+
+// ReadCABC reads the CABC mode and minimum brightness from the controller.
+// The transport must implement ReadTransport.
+func (disp *Ili948x) ReadCABC() (CABCState, error) {
+	var state CABCState
+	buf := make([]uint8, 1)
+
+	if err := disp.readCmd(CMD_RDCABC, buf); err != nil {
+		return state, err
+	}
+	state.Mode = CABCMode(buf[0] & 0x03)
+
+	if err := disp.readCmd(CMD_RDCABCMB, buf); err != nil {
+		return state, err
+	}
+	state.MinBrightness = buf[0]
+	return state, nil
+}
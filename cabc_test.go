@@ -0,0 +1,136 @@
+package ili948x_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/trace"
+)
+
+func TestSetCABC(t *testing.T) {
+	wrctrld := trace.Op{Cmd: ili948x.CMD_WRCTRLD, Params: []uint8{0x2c}}
+	rdctrld := trace.Op{Cmd: ili948x.CMD_RDCTRLD}
+	wrcabc := func(mode ili948x.CABCMode) trace.Op {
+		return trace.Op{Cmd: ili948x.CMD_WRCABC, Params: []uint8{uint8(mode)}}
+	}
+
+	for _, tt := range []struct {
+		name    string
+		opts    []ili948x.Option
+		respond []uint8 // CMD_RDCTRLD response, dummy byte first
+		first   []trace.Op
+		err     error
+	}{
+		{"control", []ili948x.Option{ili948x.WithBacklightControl(true)}, nil,
+			[]trace.Op{wrctrld, wrcabc(ili948x.CABCUI)}, nil},
+		{"no control", []ili948x.Option{ili948x.WithBacklightControl(false)}, nil,
+			nil, ili948x.ErrNotSupported},
+		{"detected", nil, []uint8{0x00, 0x2c},
+			[]trace.Op{wrctrld, rdctrld, wrcabc(ili948x.CABCUI)}, nil},
+		{"not detected", nil, []uint8{0x00, 0x00},
+			[]trace.Op{wrctrld, rdctrld}, ili948x.ErrNotSupported},
+	} {
+		rec := trace.NewRecorder()
+		disp, err := ili948x.NewIli9488(rec, nil, rec.DC(), nil, nil, 0, 0, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec.Reset()
+		rec.Respond(tt.respond...)
+
+		if err := disp.SetCABC(ili948x.CABCUI); !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+		checkOps(t, rec, tt.first)
+
+		// the control block is turned on once, for CABC and brightness alike
+		rec.Reset()
+		var want []trace.Op
+		if tt.err == nil {
+			want = []trace.Op{wrcabc(ili948x.CABCMoving), {Cmd: ili948x.CMD_WRDISBV, Params: []uint8{0x80}}}
+		}
+		if err := disp.SetCABC(ili948x.CABCMoving); !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+		if err := disp.SetBrightness(0x80); err != nil {
+			t.Fatal(err)
+		}
+		checkOps(t, rec, want)
+
+		// turning CABC off needs no control block
+		rec.Reset()
+		if err := disp.SetCABC(ili948x.CABCOff); err != nil {
+			t.Errorf("%s: off: %v", tt.name, err)
+		}
+		checkOps(t, rec, []trace.Op{wrcabc(ili948x.CABCOff)})
+	}
+
+	disp, rec := newRecorded(t)
+	if err := disp.SetCABC(ili948x.CABCMoving + 1); !errors.Is(err, ili948x.ErrNotSupported) {
+		t.Errorf("bad mode: got %v, want ErrNotSupported", err)
+	}
+	checkOps(t, rec, nil)
+}
+
+func TestSetCABCAfterReset(t *testing.T) {
+	rec := trace.NewRecorder()
+	disp, err := ili948x.NewIli9488(rec, nil, rec.DC(), nil, nil, 0, 0, ili948x.WithBacklightControl(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.SetCABC(ili948x.CABCStill); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	if err := disp.SetCABC(ili948x.CABCStill); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_WRCTRLD, Params: []uint8{0x2c}},
+		{Cmd: ili948x.CMD_WRCABC, Params: []uint8{0x02}},
+	})
+}
+
+func TestReadCABC(t *testing.T) {
+	disp, _ := newSimulated(t, ili948x.WithBacklightControl(true))
+	if state, err := disp.ReadCABC(); err != nil || state != (ili948x.CABCState{}) {
+		t.Errorf("after reset: got %+v, %v", state, err)
+	}
+	for _, want := range []ili948x.CABCState{
+		{Mode: ili948x.CABCUI, MinBrightness: 0x40},
+		{Mode: ili948x.CABCMoving, MinBrightness: 0xff},
+		{Mode: ili948x.CABCOff, MinBrightness: 0x00},
+	} {
+		if err := disp.SetCABC(want.Mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.SetCABCMinBrightness(want.MinBrightness); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := disp.ReadCABC(); err != nil || got != want {
+			t.Errorf("got %+v, %v, want %+v", got, err, want)
+		}
+	}
+
+	// without the brightness control block CABC stays off
+	disp, _ = newSimulated(t, ili948x.WithBacklightControl(false))
+	if err := disp.SetCABC(ili948x.CABCStill); !errors.Is(err, ili948x.ErrNotSupported) {
+		t.Errorf("no control: got %v, want ErrNotSupported", err)
+	}
+	if state, err := disp.ReadCABC(); err != nil || state.Mode != ili948x.CABCOff {
+		t.Errorf("no control: got %+v, %v", state, err)
+	}
+
+	rec := trace.NewRecorder()
+	wo, err := ili948x.NewIli9488(writeOnly{rec}, nil, rec.DC(), nil, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wo.ReadCABC(); !errors.Is(err, ili948x.ErrNotSupported) {
+		t.Errorf("write-only: got %v, want ErrNotSupported", err)
+	}
+}
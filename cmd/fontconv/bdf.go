@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// bdfChar is a character of a BDF font.
+type bdfChar struct {
+	code    int
+	advance int
+	bbx     [4]int // width, height, x offset, y offset of the bottom left
+	bitmap  []uint8
+}
+
+// loadBDF loads the glyphs of a BDF font in ranges.
+func loadBDF(data []byte, ranges []runeRange) (*source, error) {
+	props := map[string]string{}
+	var chars []bdfChar
+	var bbox [4]int
+	defAdvance := -1
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	var c *bdfChar
+	inBitmap, inProps := false, false
+	line := 0
+	for sc.Scan() {
+		line++
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		ints := func(n int) ([]int, error) {
+			if len(fields) < n+1 {
+				return nil, fmt.Errorf("line %d: %s: missing values", line, fields[0])
+			}
+			v := make([]int, n)
+			for i := range v {
+				var err error
+				if v[i], err = strconv.Atoi(fields[i+1]); err != nil {
+					return nil, fmt.Errorf("line %d: %v", line, err)
+				}
+			}
+			return v, nil
+		}
+
+		switch {
+		case inBitmap:
+			if fields[0] == "ENDCHAR" {
+				chars = append(chars, *c)
+				c, inBitmap = nil, false
+				continue
+			}
+			row, err := hex.DecodeString(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad bitmap row", line)
+			}
+			// rows may be padded beyond the glyph width
+			stride := (c.bbx[0] + 7) / 8
+			for len(row) < stride {
+				row = append(row, 0)
+			}
+			c.bitmap = append(c.bitmap, row[:stride]...)
+		case inProps:
+			if fields[0] == "ENDPROPERTIES" {
+				inProps = false
+				continue
+			}
+			v := strings.TrimSpace(strings.TrimPrefix(sc.Text(), fields[0]))
+			if s, err := strconv.Unquote(v); err == nil {
+				v = s
+			}
+			props[fields[0]] = v
+		case fields[0] == "STARTPROPERTIES":
+			inProps = true
+		case fields[0] == "FONT" && len(fields) > 1:
+			props["FONT"] = fields[1]
+		case fields[0] == "FONTBOUNDINGBOX":
+			v, err := ints(4)
+			if err != nil {
+				return nil, err
+			}
+			copy(bbox[:], v)
+		case fields[0] == "DWIDTH":
+			v, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			if c != nil {
+				c.advance = v[0]
+			} else {
+				defAdvance = v[0]
+			}
+		case fields[0] == "STARTCHAR":
+			c = &bdfChar{code: -1, advance: defAdvance}
+		case fields[0] == "ENCODING" && c != nil:
+			v, err := ints(1)
+			if err != nil {
+				return nil, err
+			}
+			c.code = v[0]
+		case fields[0] == "BBX" && c != nil:
+			v, err := ints(4)
+			if err != nil {
+				return nil, err
+			}
+			if v[0] < 0 || v[1] < 0 {
+				return nil, fmt.Errorf("line %d: bad BBX", line)
+			}
+			copy(c.bbx[:], v)
+		case fields[0] == "BITMAP" && c != nil:
+			inBitmap = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(chars) == 0 {
+		return nil, fmt.Errorf("not a BDF font")
+	}
+
+	decode, err := charsetDecoder(props)
+	if err != nil {
+		return nil, err
+	}
+	src := &source{desc: bitmapDesc(props, "BDF")}
+	src.ascent, err = strconv.Atoi(props["FONT_ASCENT"])
+	if err != nil {
+		src.ascent = bbox[1] + bbox[3]
+	}
+	descent, err := strconv.Atoi(props["FONT_DESCENT"])
+	if err != nil {
+		descent = -bbox[3]
+	}
+	src.height = src.ascent + descent
+
+	for _, c := range chars {
+		r, ok := decode(c.code)
+		if !ok || !contains(ranges, r) {
+			continue
+		}
+		w, h, x, y := c.bbx[0], c.bbx[1], c.bbx[2], c.bbx[3]
+		stride := (w + 7) / 8
+		if len(c.bitmap) < stride*h {
+			return nil, fmt.Errorf("glyph %U: short bitmap", r)
+		}
+		advance := c.advance
+		if advance < 0 {
+			advance = bbox[0]
+		}
+		img := image.NewAlpha(image.Rect(x, -(y + h), x+w, -y))
+		setBits(img, c.bitmap, stride)
+		src.glyphs = append(src.glyphs, srcGlyph{r: r, img: img, advance: advance})
+	}
+	return src, nil
+}
+
+// setBits sets the pixels of img to the bits of a 1 bit per pixel bitmap, most
+// significant bit first with rows stride bytes apart.
+func setBits(img *image.Alpha, bitmap []uint8, stride int) {
+	b := img.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			if bitmap[y*stride+x/8]&(0x80>>(x%8)) != 0 {
+				img.Pix[y*img.Stride+x] = 0xff
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+// testBDF has glyphs for 'A', a descender and an empty space without a
+// DWIDTH, whose advance is the width of the font bounding box.
+const testBDF = `STARTFONT 2.1
+FONT -misc-test-medium-r-normal--8-80-75-75-c-40-iso10646-1
+SIZE 8 75 75
+FONTBOUNDINGBOX 4 8 0 -2
+STARTPROPERTIES 4
+FAMILY_NAME "Test"
+PIXEL_SIZE 8
+FONT_ASCENT 6
+FONT_DESCENT 2
+ENDPROPERTIES
+CHARS 3
+STARTCHAR A
+ENCODING 65
+SWIDTH 500 0
+DWIDTH 4 0
+BBX 3 4 0 0
+BITMAP
+40
+A0
+E0
+A0
+ENDCHAR
+STARTCHAR g
+ENCODING 103
+DWIDTH 5 0
+BBX 3 4 1 -2
+BITMAP
+E0
+A0
+6000
+C0
+ENDCHAR
+STARTCHAR space
+ENCODING 32
+BBX 0 0 0 0
+BITMAP
+ENDCHAR
+ENDFONT
+`
+
+// wantGlyph is a glyph expected from a font, its rows drawn with # for ink.
+type wantGlyph struct {
+	r       rune
+	advance int
+	bounds  image.Rectangle
+	rows    []string
+}
+
+// testGlyphs are the glyphs of the test fonts.
+var testGlyphs = []wantGlyph{
+	{'A', 4, image.Rect(0, -4, 3, 0), []string{".#.", "#.#", "###", "#.#"}},
+	{'g', 5, image.Rect(1, -2, 4, 2), []string{"###", "#.#", ".##", "##."}},
+	{' ', 4, image.Rectangle{}, nil},
+}
+
+// checkGlyphs compares the glyphs of src with want, in order.
+func checkGlyphs(t *testing.T, src *source, want []wantGlyph) {
+	t.Helper()
+	if len(src.glyphs) != len(want) {
+		t.Fatalf("got %d glyphs, want %d", len(src.glyphs), len(want))
+	}
+	for i, g := range src.glyphs {
+		w := want[i]
+		if g.r != w.r || g.advance != w.advance || g.img.Bounds() != w.bounds {
+			t.Errorf("glyph %d: got %U advance %d bounds %v, want %U advance %d bounds %v",
+				i, g.r, g.advance, g.img.Bounds(), w.r, w.advance, w.bounds)
+			continue
+		}
+		var rows []string
+		b := g.img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			var row strings.Builder
+			for x := b.Min.X; x < b.Max.X; x++ {
+				switch g.img.AlphaAt(x, y).A {
+				case 0:
+					row.WriteByte('.')
+				case 0xff:
+					row.WriteByte('#')
+				default:
+					row.WriteByte('?')
+				}
+			}
+			rows = append(rows, row.String())
+		}
+		if strings.Join(rows, "|") != strings.Join(w.rows, "|") {
+			t.Errorf("glyph %U: got %q, want %q", g.r, rows, w.rows)
+		}
+	}
+}
+
+func TestLoadBDF(t *testing.T) {
+	src, err := loadBDF([]byte(testBDF), []runeRange{{0, 0x10ffff}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.height != 8 || src.ascent != 6 {
+		t.Errorf("got height %d ascent %d, want 8, 6", src.height, src.ascent)
+	}
+	if want := "the Test BDF font at 8 pixels"; src.desc != want {
+		t.Errorf("got description %q, want %q", src.desc, want)
+	}
+	checkGlyphs(t, src, testGlyphs)
+
+	// only the runes in the ranges are kept
+	src, err = loadBDF([]byte(testBDF), []runeRange{{'B', 'z'}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGlyphs(t, src, testGlyphs[1:2])
+}
+
+func TestLoadBDFMetrics(t *testing.T) {
+	// without FONT_ASCENT and FONT_DESCENT the line follows the bounding box
+	bdf := strings.Replace(testBDF, "FONT_ASCENT 6\nFONT_DESCENT 2\n", "", 1)
+	bdf = strings.Replace(bdf, "FONTBOUNDINGBOX 4 8 0 -2", "FONTBOUNDINGBOX 4 9 0 -3", 1)
+	src, err := loadBDF([]byte(bdf), []runeRange{{0, 0x10ffff}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.height != 9 || src.ascent != 6 {
+		t.Errorf("got height %d ascent %d, want 9, 6", src.height, src.ascent)
+	}
+}
+
+func TestLoadBDFCharset(t *testing.T) {
+	bdf := strings.Replace(testBDF, "ENDPROPERTIES",
+		"CHARSET_REGISTRY \"KOI8\"\nCHARSET_ENCODING \"R\"\nENDPROPERTIES", 1)
+	bdf = strings.Replace(bdf, "ENCODING 65", "ENCODING 193", 1) // KOI8-R а
+	src, err := loadBDF([]byte(bdf), []runeRange{{0, 0x10ffff}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]wantGlyph(nil), testGlyphs...)
+	want[0].r = 'а'
+	checkGlyphs(t, src, want)
+}
+
+func TestLoadBDFErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, old, new string
+		err            string
+	}{
+		{"empty", testBDF, "", "not a BDF font"},
+		{"missing values", "DWIDTH 4 0", "DWIDTH", "line 15: DWIDTH: missing values"},
+		{"bad number", "ENCODING 65", "ENCODING x", "line 13: strconv.Atoi"},
+		{"bad BBX", "BBX 3 4 0 0", "BBX -3 4 0 0", "line 16: bad BBX"},
+		{"bad bitmap row", "E0\nA0\n6000", "E0\nA0\nzz", "line 30: bad bitmap row"},
+		{"short bitmap", "E0\nA0\n6000\nC0\n", "E0\n", "glyph U+0067: short bitmap"},
+		{"charset", "ENDPROPERTIES", "CHARSET_REGISTRY \"foo\"\nENDPROPERTIES", "unsupported charset foo-"},
+	} {
+		bdf := strings.Replace(testBDF, tt.old, tt.new, 1)
+		_, err := loadBDF([]byte(bdf), []runeRange{{0, 0x10ffff}})
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// charsets maps the X11 charset registry and encoding of bitmap fonts with
+// 8 bit encodings to their character maps.
+var charsets = map[string]*charmap.Charmap{
+	"iso8859-2":        charmap.ISO8859_2,
+	"iso8859-3":        charmap.ISO8859_3,
+	"iso8859-4":        charmap.ISO8859_4,
+	"iso8859-5":        charmap.ISO8859_5,
+	"iso8859-6":        charmap.ISO8859_6,
+	"iso8859-7":        charmap.ISO8859_7,
+	"iso8859-8":        charmap.ISO8859_8,
+	"iso8859-9":        charmap.ISO8859_9,
+	"iso8859-10":       charmap.ISO8859_10,
+	"iso8859-13":       charmap.ISO8859_13,
+	"iso8859-14":       charmap.ISO8859_14,
+	"iso8859-15":       charmap.ISO8859_15,
+	"iso8859-16":       charmap.ISO8859_16,
+	"koi8-r":           charmap.KOI8R,
+	"koi8-u":           charmap.KOI8U,
+	"microsoft-cp1250": charmap.Windows1250,
+	"microsoft-cp1251": charmap.Windows1251,
+	"microsoft-cp1252": charmap.Windows1252,
+}
+
+// eucCharsets maps the X11 charset registry and encoding of bitmap fonts with
+// 94x94 character sets to their EUC encodings, the codes of which are the
+// font encodings with the high bits of both bytes set.
+var eucCharsets = map[string]encoding.Encoding{
+	"jisx0208.1983-0": japanese.EUCJP,
+	"jisx0208.1990-0": japanese.EUCJP,
+	"ksc5601.1987-0":  korean.EUCKR,
+	"ksx1001.1998-0":  korean.EUCKR,
+	"gb2312.1980-0":   simplifiedchinese.GBK,
+}
+
+// charsetDecoder returns the mapping of glyph encodings to runes of a bitmap
+// font with the given properties. Fonts without a charset are taken to be
+// encoded in Unicode.
+func charsetDecoder(props map[string]string) (func(code int) (rune, bool), error) {
+	registry := strings.ToLower(props["CHARSET_REGISTRY"])
+	encoding := strings.ToLower(props["CHARSET_ENCODING"])
+	charset := registry + "-" + encoding
+
+	switch {
+	case registry == "", registry == "iso10646", charset == "iso8859-1":
+		return func(code int) (rune, bool) {
+			return rune(code), code >= 0 && code <= 0x10ffff
+		}, nil
+	case charsets[charset] != nil:
+		cm := charsets[charset]
+		return func(code int) (rune, bool) {
+			if code < 0 || code > 0xff {
+				return 0, false
+			}
+			r := cm.DecodeByte(uint8(code))
+			return r, r != utf8.RuneError
+		}, nil
+	case eucCharsets[charset] != nil:
+		dec := eucCharsets[charset].NewDecoder()
+		return func(code int) (rune, bool) {
+			if code < 0x2121 || code > 0x7e7e {
+				return 0, false
+			}
+			b, err := dec.Bytes([]uint8{uint8(code>>8) | 0x80, uint8(code) | 0x80})
+			r, n := utf8.DecodeRune(b)
+			return r, err == nil && n == len(b) && r != utf8.RuneError
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported charset %s", charset)
+}
+
+// bitmapDesc describes a bitmap font by its properties.
+func bitmapDesc(props map[string]string, format string) string {
+	family, size := props["FAMILY_NAME"], props["PIXEL_SIZE"]
+	switch {
+	case family != "" && size != "":
+		return fmt.Sprintf("the %s %s font at %s pixels", family, format, size)
+	case props["FONT"] != "":
+		return fmt.Sprintf("the %s font %s", format, props["FONT"])
+	}
+	return "a " + format + " font"
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/inindev/ili948x"
+)
+
+// source is a font loaded for conversion: its line metrics and glyph images.
+type source struct {
+	desc   string // description for the doc comment of the font
+	height int    // line height
+	ascent int    // distance from the top of a line to the baseline
+	glyphs []srcGlyph
+}
+
+// srcGlyph is the coverage image of a glyph, with its bounds relative to the
+// pen position on the baseline.
+type srcGlyph struct {
+	r       rune
+	img     *image.Alpha
+	advance int
+}
+
+// pack converts the glyphs to an ili948x.Font with bpp bits per pixel,
+// trimming each glyph to its ink.
+func (src *source) pack(bpp int) (*ili948x.Font, error) {
+	if src.height > math.MaxUint8 || src.ascent > math.MaxUint8 {
+		return nil, fmt.Errorf("line height %d too large", src.height)
+	}
+	if len(src.glyphs) == 0 {
+		return nil, fmt.Errorf("no glyphs in the rune ranges")
+	}
+	sort.Slice(src.glyphs, func(i, j int) bool {
+		return src.glyphs[i].r < src.glyphs[j].r
+	})
+
+	font := &ili948x.Font{Height: uint8(src.height), Ascent: uint8(src.ascent), BPP: uint8(bpp)}
+	for i, sg := range src.glyphs {
+		if i > 0 && sg.r == src.glyphs[i-1].r {
+			continue
+		}
+		n := len(font.Glyphs)
+		if l := len(font.Ranges); l > 0 && font.Ranges[l-1].Last == sg.r-1 {
+			font.Ranges[l-1].Last = sg.r
+		} else {
+			font.Ranges = append(font.Ranges, ili948x.FontRange{First: sg.r, Last: sg.r, Glyph: uint16(n)})
+		}
+		if n > math.MaxUint16 {
+			return nil, fmt.Errorf("too many glyphs")
+		}
+
+		ink := inkBounds(sg.img, bpp)
+		g := ili948x.Glyph{Offset: uint32(len(font.Bitmap))}
+		if !ink.Empty() {
+			if ink.Dx() > math.MaxUint8 || ink.Dy() > math.MaxUint8 ||
+				ink.Min.X < math.MinInt8 || ink.Min.X > math.MaxInt8 ||
+				ink.Min.Y < math.MinInt8 || ink.Min.Y > math.MaxInt8 {
+				return nil, fmt.Errorf("glyph %U too large", sg.r)
+			}
+			g.Width, g.Height = uint8(ink.Dx()), uint8(ink.Dy())
+			g.XOffset, g.YOffset = int8(ink.Min.X), int8(ink.Min.Y)
+			font.Bitmap = packBits(font.Bitmap, sg.img, ink, bpp)
+		}
+		if sg.advance < 0 || sg.advance > math.MaxUint8 {
+			return nil, fmt.Errorf("glyph %U advance %d out of range", sg.r, sg.advance)
+		}
+		g.Advance = uint8(sg.advance)
+		font.Glyphs = append(font.Glyphs, g)
+	}
+	return font, nil
+}
+
+// quantize scales an 8 bit coverage to bpp bits.
+func quantize(a uint8, bpp int) uint8 {
+	max := 1<<bpp - 1
+	return uint8((int(a)*max + 127) / 255)
+}
+
+// inkBounds returns the bounds of the pixels of img which are not blank at bpp
+// bits per pixel.
+func inkBounds(img *image.Alpha, bpp int) image.Rectangle {
+	var ink image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if quantize(img.AlphaAt(x, y).A, bpp) != 0 {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return ink
+}
+
+// packBits appends the pixels of img within r to bitmap, bpp bits per pixel,
+// row by row and most significant bits first, padded to a byte boundary.
+func packBits(bitmap []uint8, img *image.Alpha, r image.Rectangle, bpp int) []uint8 {
+	var acc uint8
+	n := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			acc = acc<<bpp | quantize(img.AlphaAt(x, y).A, bpp)
+			if n += bpp; n == 8 {
+				bitmap = append(bitmap, acc)
+				acc, n = 0, 0
+			}
+		}
+	}
+	if n > 0 {
+		bitmap = append(bitmap, acc<<(8-n))
+	}
+	return bitmap
+}
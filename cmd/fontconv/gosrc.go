@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+
+	"github.com/inindev/ili948x"
+)
+
+// bytesPerLine is the number of bitmap bytes per line of Go source.
+const bytesPerLine = 16
+
+// writeGo writes font as Go source declaring the variable name in package pkg.
+func writeGo(w io.Writer, font *ili948x.Font, pkg, name, comment string) error {
+	prefix := "ili948x."
+	if pkg == "ili948x" {
+		prefix = ""
+	}
+	runes := glyphRunes(font)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by fontconv; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	if prefix != "" {
+		fmt.Fprintf(&b, "import \"github.com/inindev/ili948x\"\n\n")
+	}
+	fmt.Fprintf(&b, "// %s.\n", comment)
+	fmt.Fprintf(&b, "var %s = &%sFont{\n", name, prefix)
+	fmt.Fprintf(&b, "Height: %d,\nAscent: %d,\nBPP: %d,\n", font.Height, font.Ascent, font.BPP)
+
+	fmt.Fprintf(&b, "Ranges: []%sFontRange{\n", prefix)
+	for _, r := range font.Ranges {
+		fmt.Fprintf(&b, "{First: %#x, Last: %#x, Glyph: %d},\n", r.First, r.Last, r.Glyph)
+	}
+	fmt.Fprintf(&b, "},\n")
+
+	fmt.Fprintf(&b, "Glyphs: []%sGlyph{\n", prefix)
+	for i, g := range font.Glyphs {
+		fmt.Fprintf(&b, "{Offset: %d, Width: %d, Height: %d, XOffset: %d, YOffset: %d, Advance: %d}, // %s\n",
+			g.Offset, g.Width, g.Height, g.XOffset, g.YOffset, g.Advance, runeComment(runes[i]))
+	}
+	fmt.Fprintf(&b, "},\n")
+
+	fmt.Fprintf(&b, "Bitmap: []uint8{\n")
+	for i, g := range font.Glyphs {
+		end := len(font.Bitmap)
+		if i+1 < len(font.Glyphs) {
+			end = int(font.Glyphs[i+1].Offset)
+		}
+		if int(g.Offset) == end {
+			continue
+		}
+		fmt.Fprintf(&b, "// %s\n", runeComment(runes[i]))
+		for j, v := range font.Bitmap[g.Offset:end] {
+			fmt.Fprintf(&b, "%#02x,", v)
+			if (j+1)%bytesPerLine == 0 || int(g.Offset)+j+1 == end {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+	}
+	fmt.Fprintf(&b, "},\n}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// glyphRunes returns the rune of each glyph of font.
+func glyphRunes(font *ili948x.Font) []rune {
+	runes := make([]rune, len(font.Glyphs))
+	for _, r := range font.Ranges {
+		for c := r.First; c <= r.Last; c++ {
+			runes[int(r.Glyph)+int(c-r.First)] = c
+		}
+	}
+	return runes
+}
+
+// runeComment returns r as a quoted character if printable ASCII, otherwise
+// as U+XXXX.
+func runeComment(r rune) string {
+	if r >= 0x20 && r < 0x7f {
+		return strconv.QuoteRune(r)
+	}
+	return fmt.Sprintf("%U", r)
+}
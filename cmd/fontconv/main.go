@@ -0,0 +1,188 @@
+// fontconv converts fonts to the ili948x.Font format, as Go source or as a
+// binary blob for ili948x.ParseFont.
+//
+// TrueType and OpenType fonts are rasterized at the given pixel size, with 1
+// bit per pixel or anti-aliased with 2, 4 or 8 bits of coverage per pixel:
+//
+//	go run ./cmd/fontconv -size 48 -bpp 4 -runes 0x20-0x7e,0xb0 \
+//		-name FontSans48 -o fontsans48.go DejaVuSans.ttf
+//
+// BDF and PCF bitmap fonts, optionally gzipped, are converted as they are.
+// Only the runes in -runes are kept, subsetting large fonts to save flash:
+//
+//	go run ./cmd/fontconv -format bin -runes 0x20-0x7e,0xa0-0xff \
+//		-o 9x15.bin 9x15.pcf.gz
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	size := flag.Float64("size", 16, "TrueType / OpenType pixel size")
+	bpp := flag.Int("bpp", 0, "bits per pixel: 1, 2, 4 or 8 (default 4, 1 for bitmap fonts)")
+	runes := flag.String("runes", "0x20-0x7e", "comma separated runes and rune ranges, e.g. 0x20-0x7e,0xb0, or all")
+	format := flag.String("format", "go", "output format: go or bin")
+	name := flag.String("name", "Font", "Go variable name")
+	pkg := flag.String("pkg", "main", "Go package name")
+	out := flag.String("o", "", "output file, stdout if empty")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: fontconv [flags] font.ttf|font.otf|font.bdf|font.pcf\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *out, *format, *size, *bpp, *runes, *name, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "fontconv:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, format string, size float64, bpp int, runes, name, pkg string) error {
+	switch bpp {
+	case 0, 1, 2, 4, 8:
+	default:
+		return fmt.Errorf("unsupported bits per pixel: %d", bpp)
+	}
+	if format != "go" && format != "bin" {
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+	ranges, err := parseRanges(runes)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(in)
+	if err != nil {
+		return err
+	}
+	ext := strings.ToLower(filepath.Ext(in))
+	if ext == ".gz" {
+		if data, err = gunzip(data); err != nil {
+			return fmt.Errorf("%s: %v", in, err)
+		}
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(in, filepath.Ext(in))))
+	}
+
+	var src *source
+	switch ext {
+	case ".ttf", ".otf", ".ttc", ".otc":
+		if bpp == 0 {
+			bpp = 4
+		}
+		src, err = loadOpenType(data, size, ranges)
+	case ".bdf":
+		src, err = loadBDF(data, ranges)
+	case ".pcf":
+		src, err = loadPCF(data, ranges)
+	default:
+		return fmt.Errorf("%s: unknown font format", in)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", in, err)
+	}
+
+	if bpp == 0 {
+		bpp = 1
+	}
+	font, err := src.pack(bpp)
+	if err != nil {
+		return fmt.Errorf("%s: %v", in, err)
+	}
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if format == "bin" {
+		b, err := font.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+	comment := fmt.Sprintf("%s is %s", name, src.desc)
+	return writeGo(w, font, pkg, name, comment)
+}
+
+// gunzip decompresses gzipped data.
+func gunzip(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+// runeRange is an inclusive range of runes.
+type runeRange struct {
+	first, last rune
+}
+
+// parseRanges parses comma separated runes and rune ranges, e.g. 0x20-0x7e,0xb0,
+// or all for every rune.
+func parseRanges(s string) ([]runeRange, error) {
+	if s == "all" {
+		return []runeRange{{0, 0x10ffff}}, nil
+	}
+	var ranges []runeRange
+	for _, f := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(f), "-")
+		first, err := parseRune(lo)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseRune(hi); err != nil {
+				return nil, err
+			}
+		}
+		if last < first {
+			return nil, fmt.Errorf("bad rune range: %s", f)
+		}
+		ranges = append(ranges, runeRange{first, last})
+	}
+	return ranges, nil
+}
+
+// parseRune parses a rune as a number, e.g. 0x41 or 65, or U+0041.
+func parseRune(s string) (rune, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "U+") || strings.HasPrefix(s, "u+") {
+		s = "0x" + s[2:]
+	}
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil || v > 0x10ffff {
+		return 0, fmt.Errorf("bad rune: %q", s)
+	}
+	return rune(v), nil
+}
+
+// contains reports whether r is in one of the ranges.
+func contains(ranges []runeRange, r rune) bool {
+	for _, rr := range ranges {
+		if r >= rr.first && r <= rr.last {
+			return true
+		}
+	}
+	return false
+}
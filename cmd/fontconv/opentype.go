@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// loadOpenType rasterizes the runes of a TrueType or OpenType font in ranges
+// at size pixels. Runes the font has no glyph for are skipped.
+func loadOpenType(data []byte, size float64, ranges []runeRange) (*source, error) {
+	coll, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, err
+	}
+	f, err := coll.Font(0)
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72, // 1 point per pixel
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	var buf sfnt.Buffer
+	name, err := f.Name(&buf, sfnt.NameIDFull)
+	if err != nil {
+		name = "a font"
+	}
+	m := face.Metrics()
+	src := &source{
+		desc:   fmt.Sprintf("%s at %g pixels", name, size),
+		height: m.Height.Ceil(),
+		ascent: m.Ascent.Ceil(),
+	}
+	if h := src.ascent + m.Descent.Ceil(); h > src.height {
+		src.height = h
+	}
+
+	for _, rr := range ranges {
+		for r := rr.first; r <= rr.last; r++ {
+			if x, err := f.GlyphIndex(&buf, r); err != nil || x == 0 {
+				continue
+			}
+			dr, mask, mp, advance, ok := face.Glyph(fixed.Point26_6{}, r)
+			if !ok {
+				continue
+			}
+			img := image.NewAlpha(dr)
+			draw.Draw(img, dr, mask, mp, draw.Src)
+			src.glyphs = append(src.glyphs, srcGlyph{r: r, img: img, advance: advance.Round()})
+		}
+	}
+	return src, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestLoadOpenType(t *testing.T) {
+	// Go Regular has no glyph for U+4E00
+	src, err := loadOpenType(goregular.TTF, 16, []runeRange{{'A', 'C'}, {'g', 'g'}, {' ', ' '}, {0x4e00, 0x4e00}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Go Regular at 16 pixels"; src.desc != want {
+		t.Errorf("got description %q, want %q", src.desc, want)
+	}
+	if src.height != 20 || src.ascent != 16 {
+		t.Errorf("got height %d ascent %d, want 20, 16", src.height, src.ascent)
+	}
+	for i, want := range []struct {
+		r       rune
+		advance int
+		bounds  image.Rectangle
+	}{
+		{'A', 11, image.Rect(0, -12, 11, 0)},
+		{'B', 11, image.Rect(1, -12, 10, 0)},
+		{'C', 12, image.Rect(0, -12, 11, 1)},
+		{'g', 9, image.Rect(0, -9, 8, 4)}, // below the baseline
+		{' ', 4, image.Rectangle{}},
+	} {
+		if i >= len(src.glyphs) {
+			t.Fatalf("got %d glyphs", len(src.glyphs))
+		}
+		g := src.glyphs[i]
+		if g.r != want.r || g.advance != want.advance || g.img.Bounds() != want.bounds {
+			t.Errorf("glyph %d: got %U advance %d bounds %v, want %U advance %d bounds %v",
+				i, g.r, g.advance, g.img.Bounds(), want.r, want.advance, want.bounds)
+		}
+	}
+	if len(src.glyphs) != 5 {
+		t.Errorf("got %d glyphs, want 5", len(src.glyphs))
+	}
+
+	if _, err := loadOpenType([]byte("not a font"), 16, []runeRange{{'A', 'A'}}); err == nil {
+		t.Errorf("loaded a bad font")
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	for _, tt := range []struct {
+		a    uint8
+		bpp  int
+		want uint8
+	}{
+		{0, 1, 0}, {127, 1, 0}, {128, 1, 1}, {255, 1, 1},
+		{42, 2, 0}, {43, 2, 1}, {212, 2, 2}, {213, 2, 3},
+		{8, 4, 0}, {9, 4, 1}, {255, 4, 15},
+		{0, 8, 0}, {200, 8, 200}, {255, 8, 255},
+	} {
+		if got := quantize(tt.a, tt.bpp); got != tt.want {
+			t.Errorf("quantize(%d, %d) = %d, want %d", tt.a, tt.bpp, got, tt.want)
+		}
+	}
+}
+
+func TestPackOpenType(t *testing.T) {
+	src, err := loadOpenType(goregular.TTF, 16, []runeRange{{'A', 'C'}, {'g', 'g'}, {' ', ' '}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bpp := range []int{1, 2, 4, 8} {
+		font, err := src.pack(bpp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if font.Height != 20 || font.Ascent != 16 || int(font.BPP) != bpp {
+			t.Errorf("%d bpp: got height %d ascent %d bpp %d", bpp, font.Height, font.Ascent, font.BPP)
+		}
+
+		// each glyph is trimmed to its ink and holds the quantized coverage
+		mask := 1<<bpp - 1
+		for _, sg := range src.glyphs {
+			g := font.Glyph(sg.r)
+			if g == nil || g.Advance != uint8(sg.advance) {
+				t.Fatalf("%d bpp: glyph %U missing or advance changed", bpp, sg.r)
+			}
+			ink := image.Rect(int(g.XOffset), int(g.YOffset), int(g.XOffset)+int(g.Width), int(g.YOffset)+int(g.Height))
+			b := sg.img.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					want := quantize(sg.img.AlphaAt(x, y).A, bpp)
+					if !image.Pt(x, y).In(ink) {
+						if want != 0 {
+							t.Errorf("%d bpp: glyph %U: %d, %d outside the ink %v", bpp, sg.r, x, y, ink)
+						}
+						continue
+					}
+					i := ((y-ink.Min.Y)*ink.Dx() + x - ink.Min.X) * bpp
+					got := font.Bitmap[int(g.Offset)+i/8] >> (8 - bpp - i%8) & uint8(mask)
+					if got != want {
+						t.Errorf("%d bpp: glyph %U: pixel %d, %d = %d, want %d", bpp, sg.r, x, y, got, want)
+					}
+				}
+			}
+		}
+		// anti-aliasing gives intermediate levels
+		if g := font.Glyph('C'); bpp > 1 && !hasLevel(font.Bitmap[g.Offset:], bpp) {
+			t.Errorf("%d bpp: no intermediate coverage", bpp)
+		}
+	}
+}
+
+// hasLevel reports whether bitmap has a value between 0 and full coverage.
+func hasLevel(bitmap []uint8, bpp int) bool {
+	mask := uint8(1<<bpp - 1)
+	for _, b := range bitmap {
+		for s := 8 - bpp; s >= 0; s -= bpp {
+			if v := b >> s & mask; v != 0 && v != mask {
+				return true
+			}
+		}
+	}
+	return false
+}
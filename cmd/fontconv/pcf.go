@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+)
+
+// https://fontforge.org/docs/techref/pcf-format.html
+const (
+	pcfProperties      = 1 << 0
+	pcfAccelerators    = 1 << 1
+	pcfMetrics         = 1 << 2
+	pcfBitmaps         = 1 << 3
+	pcfBDFEncodings    = 1 << 5
+	pcfBDFAccelerators = 1 << 8
+
+	pcfByteMSB           = 1 << 2
+	pcfBitMSB            = 1 << 3
+	pcfCompressedMetrics = 0x100
+)
+
+// pcfTable reads a table of a PCF font in the byte order of its format.
+type pcfTable struct {
+	format uint32
+	b      []byte
+	err    error
+}
+
+func (t *pcfTable) next(n int) []byte {
+	if t.err != nil || n < 0 || n > len(t.b) {
+		t.err = fmt.Errorf("truncated table")
+		t.b = nil
+		return make([]byte, 4)
+	}
+	b := t.b[:n]
+	t.b = t.b[n:]
+	return b
+}
+
+func (t *pcfTable) order() binary.ByteOrder {
+	if t.format&pcfByteMSB != 0 {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+func (t *pcfTable) u8() int  { return int(t.next(1)[0]) }
+func (t *pcfTable) i16() int { return int(int16(t.order().Uint16(t.next(2)))) }
+func (t *pcfTable) u16() int { return int(t.order().Uint16(t.next(2))) }
+func (t *pcfTable) i32() int { return int(int32(t.order().Uint32(t.next(4)))) }
+
+// count reads a 32 bit count of entries of at least size bytes each which
+// must fit in the rest of the table.
+func (t *pcfTable) count(size int) int {
+	n := t.i32()
+	if n < 0 || n > len(t.b)/size {
+		if t.err == nil {
+			t.err = fmt.Errorf("bad count %d", n)
+		}
+		return 0
+	}
+	return n
+}
+
+// pcfMetric is the bounding box and advance of a glyph.
+type pcfMetric struct {
+	left, right, advance, ascent, descent int
+}
+
+// loadPCF loads the glyphs of a PCF font in ranges.
+func loadPCF(data []byte, ranges []runeRange) (*source, error) {
+	if len(data) < 8 || string(data[:4]) != "\x01fcp" {
+		return nil, fmt.Errorf("not a PCF font")
+	}
+	tables := map[uint32]*pcfTable{}
+	n := int(binary.LittleEndian.Uint32(data[4:]))
+	if n > (len(data)-8)/16 {
+		return nil, fmt.Errorf("truncated table of contents")
+	}
+	for i := 0; i < n; i++ {
+		e := data[8+i*16:]
+		typ := binary.LittleEndian.Uint32(e)
+		size := uint64(binary.LittleEndian.Uint32(e[8:]))
+		offs := uint64(binary.LittleEndian.Uint32(e[12:]))
+		if size < 4 || offs+size > uint64(len(data)) {
+			return nil, fmt.Errorf("bad table %#x", typ)
+		}
+		b := data[offs : offs+size]
+		tables[typ] = &pcfTable{format: binary.LittleEndian.Uint32(b), b: b[4:]}
+	}
+	table := func(typ uint32) (*pcfTable, error) {
+		if t := tables[typ]; t != nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("missing table %#x", typ)
+	}
+
+	// properties
+	props := map[string]string{}
+	t, err := table(pcfProperties)
+	if err != nil {
+		return nil, err
+	}
+	type prop struct {
+		name, value int
+		isString    bool
+	}
+	list := make([]prop, t.count(9))
+	for i := range list {
+		list[i] = prop{name: t.i32(), isString: t.u8() != 0, value: t.i32()}
+	}
+	if len(list)&3 != 0 {
+		t.next(4 - len(list)&3)
+	}
+	strs := t.next(t.count(1))
+	str := func(offs int) string {
+		if offs < 0 || offs >= len(strs) {
+			return ""
+		}
+		s := strs[offs:]
+		for i, c := range s {
+			if c == 0 {
+				return string(s[:i])
+			}
+		}
+		return string(s)
+	}
+	for _, p := range list {
+		if p.isString {
+			props[str(p.name)] = str(p.value)
+		} else {
+			props[str(p.name)] = strconv.Itoa(p.value)
+		}
+	}
+	if t.err != nil {
+		return nil, fmt.Errorf("properties: %v", t.err)
+	}
+
+	// line metrics
+	t = tables[pcfBDFAccelerators]
+	if t == nil {
+		if t, err = table(pcfAccelerators); err != nil {
+			return nil, err
+		}
+	}
+	t.next(8)
+	src := &source{desc: bitmapDesc(props, "PCF")}
+	src.ascent = t.i32()
+	src.height = src.ascent + t.i32()
+	if t.err != nil {
+		return nil, fmt.Errorf("accelerators: %v", t.err)
+	}
+
+	// glyph metrics
+	if t, err = table(pcfMetrics); err != nil {
+		return nil, err
+	}
+	var metrics []pcfMetric
+	if t.format&pcfCompressedMetrics != 0 {
+		metrics = make([]pcfMetric, t.u16())
+		for i := range metrics {
+			metrics[i] = pcfMetric{t.u8() - 0x80, t.u8() - 0x80, t.u8() - 0x80, t.u8() - 0x80, t.u8() - 0x80}
+		}
+	} else {
+		metrics = make([]pcfMetric, t.count(12))
+		for i := range metrics {
+			metrics[i] = pcfMetric{t.i16(), t.i16(), t.i16(), t.i16(), t.i16()}
+			t.u16() // attributes
+		}
+	}
+	if t.err != nil {
+		return nil, fmt.Errorf("metrics: %v", t.err)
+	}
+
+	// bitmaps
+	if t, err = table(pcfBitmaps); err != nil {
+		return nil, err
+	}
+	offsets := make([]int, t.count(4))
+	for i := range offsets {
+		offsets[i] = t.i32()
+	}
+	var sizes [4]int
+	for i := range sizes {
+		sizes[i] = t.i32()
+	}
+	bitmap := append([]byte(nil), t.next(sizes[t.format&3])...)
+	if t.err != nil {
+		return nil, fmt.Errorf("bitmaps: %v", t.err)
+	}
+	normalizeBits(bitmap, t.format)
+	pad := 1 << (t.format & 3)
+
+	// encodings
+	if t, err = table(pcfBDFEncodings); err != nil {
+		return nil, err
+	}
+	min2, max2, min1, max1 := t.i16(), t.i16(), t.i16(), t.i16()
+	t.i16() // default char
+	if t.err != nil {
+		return nil, fmt.Errorf("encodings: %v", t.err)
+	}
+
+	decode, err := charsetDecoder(props)
+	if err != nil {
+		return nil, err
+	}
+	for b1 := min1; b1 <= max1; b1++ {
+		for b2 := min2; b2 <= max2; b2++ {
+			i := t.u16()
+			if t.err != nil {
+				return nil, fmt.Errorf("encodings: %v", t.err)
+			}
+			r, ok := decode(b1<<8 | b2)
+			if i == 0xffff || !ok || !contains(ranges, r) {
+				continue
+			}
+			if i >= len(metrics) || i >= len(offsets) {
+				return nil, fmt.Errorf("glyph %U: bad index", r)
+			}
+			m := metrics[i]
+			w, h := m.right-m.left, m.ascent+m.descent
+			stride := ((w+7)/8 + pad - 1) / pad * pad
+			if w < 0 || h < 0 || offsets[i] < 0 || offsets[i]+stride*h > len(bitmap) {
+				return nil, fmt.Errorf("glyph %U: bad bitmap", r)
+			}
+			img := image.NewAlpha(image.Rect(m.left, -m.ascent, m.right, m.descent))
+			setBits(img, bitmap[offsets[i]:], stride)
+			src.glyphs = append(src.glyphs, srcGlyph{r: r, img: img, advance: m.advance})
+		}
+	}
+	return src, nil
+}
+
+// normalizeBits converts bitmap data of a PCF format to most significant bit
+// and byte first.
+func normalizeBits(b []byte, format uint32) {
+	unit := 1 << (format >> 4 & 3)
+	if (format&pcfByteMSB != 0) != (format&pcfBitMSB != 0) {
+		for i := 0; i+unit <= len(b); i += unit {
+			for j, k := i, i+unit-1; j < k; j, k = j+1, k-1 {
+				b[j], b[k] = b[k], b[j]
+			}
+		}
+	}
+	if format&pcfBitMSB == 0 {
+		for i := range b {
+			b[i] = bits.Reverse8(b[i])
+		}
+	}
+}
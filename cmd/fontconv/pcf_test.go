@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/bits"
+	"strings"
+	"testing"
+)
+
+// pcfGlyph is a glyph to encode in a PCF font, rows one byte each.
+type pcfGlyph struct {
+	code                               int
+	left, right, advance, ascent, desc int
+	rows                               []uint8
+}
+
+// testPCFGlyphs are the glyphs of testBDF.
+var testPCFGlyphs = []pcfGlyph{
+	{'A', 0, 3, 4, 4, 0, []uint8{0x40, 0xa0, 0xe0, 0xa0}},
+	{'g', 1, 4, 5, 2, 2, []uint8{0xe0, 0xa0, 0x60, 0xc0}},
+	{' ', 0, 0, 4, 0, 0, nil},
+}
+
+// pcfWriter writes the tables of a PCF font in the byte order of format.
+type pcfWriter struct {
+	format uint32
+	bytes.Buffer
+}
+
+func (w *pcfWriter) order() binary.ByteOrder {
+	if w.format&pcfByteMSB != 0 {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+func (w *pcfWriter) u8(v int)  { w.WriteByte(uint8(v)) }
+func (w *pcfWriter) i16(v int) { binary.Write(w, w.order(), int16(v)) }
+func (w *pcfWriter) i32(v int) { binary.Write(w, w.order(), int32(v)) }
+
+// encodePCF returns a PCF font with the glyphs, the bitmaps and all tables
+// in the given format. The glyph codes must be single bytes.
+func encodePCF(format uint32, compressed bool, props map[string]string, glyphs []pcfGlyph) []byte {
+	type table struct {
+		typ    uint32
+		format uint32
+		data   []byte
+	}
+	var tables []table
+	add := func(typ uint32, w *pcfWriter) {
+		tables = append(tables, table{typ, w.format, w.Bytes()})
+	}
+
+	// properties, all strings
+	w := &pcfWriter{format: format}
+	var strs bytes.Buffer
+	w.i32(len(props))
+	for name, value := range props {
+		w.i32(strs.Len())
+		strs.WriteString(name + "\x00")
+		w.u8(1)
+		w.i32(strs.Len())
+		strs.WriteString(value + "\x00")
+	}
+	if len(props)&3 != 0 {
+		w.Write(make([]byte, 4-len(props)&3))
+	}
+	w.i32(strs.Len())
+	w.Write(strs.Bytes())
+	add(pcfProperties, w)
+
+	// accelerators: flags, overlap, then the font ascent and descent
+	w = &pcfWriter{format: format}
+	w.Write(make([]byte, 8))
+	w.i32(6)
+	w.i32(2)
+	add(pcfBDFAccelerators, w)
+
+	w = &pcfWriter{format: format}
+	if compressed {
+		w.format |= pcfCompressedMetrics
+		binary.Write(w, w.order(), uint16(len(glyphs)))
+		for _, g := range glyphs {
+			for _, v := range []int{g.left, g.right, g.advance, g.ascent, g.desc} {
+				w.u8(v + 0x80)
+			}
+		}
+	} else {
+		w.i32(len(glyphs))
+		for _, g := range glyphs {
+			for _, v := range []int{g.left, g.right, g.advance, g.ascent, g.desc, 0} {
+				w.i16(v)
+			}
+		}
+	}
+	add(pcfMetrics, w)
+
+	// bitmaps, rows padded to 1 << (format & 3) bytes
+	w = &pcfWriter{format: format}
+	var sizes [4]int
+	var bitmap []byte
+	for p := range sizes {
+		for _, g := range glyphs {
+			sizes[p] += len(g.rows) << p
+		}
+	}
+	w.i32(len(glyphs))
+	for _, g := range glyphs {
+		w.i32(len(bitmap))
+		for _, row := range g.rows {
+			b := make([]byte, 1<<(format&3))
+			b[0] = row
+			bitmap = append(bitmap, b...)
+		}
+	}
+	for _, s := range sizes {
+		w.i32(s)
+	}
+	if format&pcfBitMSB == 0 {
+		for i := range bitmap {
+			bitmap[i] = bits.Reverse8(bitmap[i])
+		}
+	}
+	if unit := 1 << (format >> 4 & 3); (format&pcfByteMSB != 0) != (format&pcfBitMSB != 0) {
+		for i := 0; i < len(bitmap); i += unit {
+			u := bitmap[i : i+unit]
+			for j := 0; j < unit/2; j++ {
+				u[j], u[unit-1-j] = u[unit-1-j], u[j]
+			}
+		}
+	}
+	w.Write(bitmap)
+	add(pcfBitmaps, w)
+
+	// encodings of the codes 0x20 to 0x7f
+	w = &pcfWriter{format: format}
+	w.i16(0x20)
+	w.i16(0x7f)
+	w.i16(0)
+	w.i16(0)
+	w.i16(0)
+	index := make([]int, 0x60)
+	for i := range index {
+		index[i] = 0xffff
+	}
+	for i, g := range glyphs {
+		index[g.code-0x20] = i
+	}
+	for _, i := range index {
+		binary.Write(w, w.order(), uint16(i))
+	}
+	add(pcfBDFEncodings, w)
+
+	var file bytes.Buffer
+	le := binary.LittleEndian
+	file.WriteString("\x01fcp")
+	binary.Write(&file, le, uint32(len(tables)))
+	offs := 8 + len(tables)*16
+	for _, t := range tables {
+		for _, v := range []int{int(t.typ), int(t.format), 4 + len(t.data), offs} {
+			binary.Write(&file, le, uint32(v))
+		}
+		offs += 4 + len(t.data)
+	}
+	for _, t := range tables {
+		binary.Write(&file, le, t.format)
+		file.Write(t.data)
+	}
+	return file.Bytes()
+}
+
+var testPCFProps = map[string]string{"FAMILY_NAME": "Test", "PIXEL_SIZE": "8"}
+
+func TestLoadPCF(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		format     uint32
+		compressed bool
+	}{
+		{"lsb", 0, false},
+		{"msb", pcfByteMSB | pcfBitMSB | 2 | 2<<4, false},        // 4 byte rows and units
+		{"msb byte lsb bit", pcfByteMSB | 2 | 1<<4, false},       // 2 byte units
+		{"lsb byte msb bit", pcfBitMSB | 3 | 2<<4, false},        // 8 byte rows
+		{"compressed metrics", pcfByteMSB | pcfBitMSB | 1, true}, // 2 byte rows
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pcf := encodePCF(tt.format, tt.compressed, testPCFProps, testPCFGlyphs)
+			src, err := loadPCF(pcf, []runeRange{{0, 0x10ffff}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if src.height != 8 || src.ascent != 6 {
+				t.Errorf("got height %d ascent %d, want 8, 6", src.height, src.ascent)
+			}
+			if want := "the Test PCF font at 8 pixels"; src.desc != want {
+				t.Errorf("got description %q, want %q", src.desc, want)
+			}
+			// the glyphs are loaded in the order of their codes
+			checkGlyphs(t, src, []wantGlyph{testGlyphs[2], testGlyphs[0], testGlyphs[1]})
+		})
+	}
+
+	pcf := encodePCF(0, false, testPCFProps, testPCFGlyphs)
+	src, err := loadPCF(pcf, []runeRange{{'B', 'z'}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGlyphs(t, src, testGlyphs[1:2])
+}
+
+func TestLoadPCFErrors(t *testing.T) {
+	pcf := encodePCF(0, false, testPCFProps, testPCFGlyphs)
+	le := binary.LittleEndian
+
+	// patch returns a copy of pcf with a little endian uint32 at offs
+	patch := func(offs int, v uint32) []byte {
+		b := append([]byte(nil), pcf...)
+		le.PutUint32(b[offs:], v)
+		return b
+	}
+	toc := func(table, field int) int { return 8 + table*16 + field*4 }
+	metrics := int(le.Uint32(pcf[toc(2, 3):])) + 4
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"empty", nil, "not a PCF font"},
+		{"magic", append([]byte("\x01fcq"), pcf[4:]...), "not a PCF font"},
+		{"table count", patch(4, 1000), "truncated table of contents"},
+		{"table size", patch(toc(1, 2), 0x10000), "bad table 0x100"},
+		{"missing table", patch(toc(4, 0), 0), "missing table 0x20"},
+		{"metrics count", patch(metrics, 1000), "metrics: bad count 1000"},
+		{"truncated", pcf[:len(pcf)-2], "bad table 0x20"},
+	} {
+		_, err := loadPCF(tt.data, []runeRange{{0, 0x10ffff}})
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package ili948x
+
+import (
+	"unicode/utf8"
+)
+
+const consoleTabWidth = 8
+
+// Console is a text console on the scroll area of the display. It implements
+// io.Writer: text wraps at the right edge, and when the cursor passes the
+// last row, the row scrolled out at the top is cleared and reused as the new
+// last row by advancing the hardware scroll start address, without redrawing
+// the text above. \n starts a new line, \r returns to the start of the line,
+// \t advances to the next tab stop and \b moves the cursor back. The fixed
+// header and footer areas are left to the caller.
+//
+// The hardware scrolls along the panel's gate lines, which are rows only in
+// Rot_0 and Rot_180. In Rot_90 and Rot_270 the Console keeps its text and
+// scrolls by redrawing the rows, which is much slower.
+type Console struct {
+	disp   *Ili948x
+	font   *Font
+	fg, bg uint32
+
+	top   uint16 // first line of the scroll area
+	lines uint16 // lines in the scroll area
+	cw    int16  // cell width
+	ch    int16  // cell height
+	cols  int
+	rows  int
+
+	col, row int      // cursor
+	first    uint16   // scroll area line of the top row
+	pending  [4]uint8 // incomplete utf-8 sequence
+	npending int
+	cell     []uint32
+	text     []consoleCell // rows of text, nil with the hardware scroll
+}
+
+// consoleCell is a character of a Console scrolled without the hardware.
+type consoleCell struct {
+	r      rune
+	fg, bg uint32
+}
+
+// NewConsole returns a Console using the display between a header and a footer
+// of the given heights, drawing with font in colors fg on bg. The console is
+// cleared and, in Rot_0 and Rot_180, the hardware scroll area set up. Rows
+// that do not fill a text line are added to the footer.
+func NewConsole(disp *Ili948x, font *Font, fg, bg uint32, header, footer uint16) (*Console, error) {
+	const op = "NewConsole"
+	width, height := disp.Size()
+	cw := int16(1)
+	if g := font.Glyph('M'); g != nil && g.Advance > 0 {
+		cw = int16(g.Advance)
+	}
+	ch := int16(font.Height)
+	if uint32(header)+uint32(footer) > uint32(height) {
+		return nil, &Error{Op: op, Kind: ErrOutOfBounds}
+	}
+	rows := int(uint16(height)-header-footer) / int(ch)
+	cols := int(width / cw)
+	if rows == 0 || cols == 0 || font.Height == 0 {
+		return nil, &Error{Op: op, Kind: ErrOutOfBounds}
+	}
+
+	c := &Console{
+		disp:  disp,
+		font:  font,
+		fg:    fg,
+		bg:    bg,
+		top:   header,
+		lines: uint16(rows) * uint16(ch),
+		cw:    cw,
+		ch:    ch,
+		cols:  cols,
+		rows:  rows,
+		cell:  make([]uint32, int(cw)*int(ch)),
+	}
+	if disp.rot == Rot_90 || disp.rot == Rot_270 {
+		c.text = make([]consoleCell, cols*rows)
+	} else if err := disp.SetScrollArea(c.top, uint16(height)-c.top-c.lines); err != nil {
+		return nil, err
+	}
+	if err := c.Clear(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// SetColors sets the colors of the text written from now on.
+func (c *Console) SetColors(fg, bg uint32) {
+	c.fg, c.bg = fg, bg
+}
+
+// Size returns the number of columns and rows of the console.
+func (c *Console) Size() (cols, rows int) {
+	return c.cols, c.rows
+}
+
+// Clear clears the scroll area, resets the scroll start address and moves
+// the cursor to the top left.
+func (c *Console) Clear() error {
+	width, _ := c.disp.Size()
+	if err := c.disp.FillRectangle(0, int16(c.top), width, int16(c.lines), c.bg); err != nil {
+		return err
+	}
+	for i := range c.text {
+		c.text[i] = consoleCell{r: ' ', fg: c.fg, bg: c.bg}
+	}
+	if c.text == nil {
+		if err := c.disp.SetScroll(c.top); err != nil {
+			return err
+		}
+	}
+	c.col, c.row, c.first = 0, 0, 0
+	return nil
+}
+
+// Write writes p to the console, decoding it as UTF-8. Sequences split
+// between writes are joined.
+func (c *Console) Write(p []uint8) (int, error) {
+	for i := 0; i < len(p); i++ {
+		c.pending[c.npending] = p[i]
+		c.npending++
+		if !utf8.FullRune(c.pending[:c.npending]) {
+			continue
+		}
+		r, _ := utf8.DecodeRune(c.pending[:c.npending])
+		c.npending = 0
+		if err := c.writeRune(r); err != nil {
+			return i, err
+		}
+	}
+	return len(p), nil
+}
+
+// WriteString writes s to the console.
+func (c *Console) WriteString(s string) (int, error) {
+	return c.Write([]uint8(s))
+}
+
+// writeRune handles a control character or draws a printable rune at the
+// cursor.
+func (c *Console) writeRune(r rune) error {
+	switch r {
+	case '\n':
+		c.col = 0
+		return c.newline()
+	case '\r':
+		c.col = 0
+	case '\t':
+		next := (c.col/consoleTabWidth + 1) * consoleTabWidth
+		for c.col < next && c.col < c.cols {
+			if err := c.put(' '); err != nil {
+				return err
+			}
+		}
+	case '\b':
+		if c.col > 0 {
+			c.col--
+		}
+	default:
+		if r < ' ' || r == 0x7f {
+			return nil // other control characters
+		}
+		return c.put(r)
+	}
+	return nil
+}
+
+// put draws r at the cursor and advances it, wrapping at the right edge.
+func (c *Console) put(r rune) error {
+	if c.col == c.cols {
+		c.col = 0
+		if err := c.newline(); err != nil {
+			return err
+		}
+	}
+	if c.text != nil {
+		c.text[c.row*c.cols+c.col] = consoleCell{r: r, fg: c.fg, bg: c.bg}
+	}
+	if err := c.drawCell(c.col, c.row, r, c.fg, c.bg); err != nil {
+		return err
+	}
+	c.col++
+	return nil
+}
+
+// drawCell draws r in colors fg on bg at a cell.
+func (c *Console) drawCell(col, row int, r rune, fg, bg uint32) error {
+	c.font.renderCell(c.cell, int(c.cw), int(c.ch), c.font.Glyph(r), fg, bg)
+	return c.disp.writeWindow(uint16(col)*uint16(c.cw), c.line(row), uint16(c.cw), uint16(c.ch), c.cell)
+}
+
+// newline moves the cursor down a row, scrolling when it is on the last row.
+func (c *Console) newline() error {
+	if c.row < c.rows-1 {
+		c.row++
+		return nil
+	}
+	if c.text != nil {
+		return c.redrawUp()
+	}
+
+	// clear the top row and scroll it in at the bottom
+	width, _ := c.disp.Size()
+	if err := c.disp.FillRectangle(0, int16(c.line(0)), width, c.ch, c.bg); err != nil {
+		return err
+	}
+	c.first = (c.first + uint16(c.ch)) % c.lines
+	return c.disp.scrollForward(c.first)
+}
+
+// redrawUp scrolls the text up a row without the hardware scroll, redrawing
+// every row and clearing the last one.
+func (c *Console) redrawUp() error {
+	copy(c.text, c.text[c.cols:])
+	last := c.text[(c.rows-1)*c.cols:]
+	for i := range last {
+		last[i] = consoleCell{r: ' ', fg: c.fg, bg: c.bg}
+	}
+	for row := 0; row < c.rows; row++ {
+		for col := 0; col < c.cols; col++ {
+			t := c.text[row*c.cols+col]
+			if err := c.drawCell(col, row, t.r, t.fg, t.bg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// line returns the display line of the top of a row.
+func (c *Console) line(row int) uint16 {
+	return c.top + (c.first+uint16(row)*uint16(c.ch))%c.lines
+}
@@ -0,0 +1,140 @@
+package ili948x_test
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/sim"
+)
+
+// newConsoleAt returns a console between a header and a footer on a
+// simulated display in the given rotation and scroll direction.
+func newConsoleAt(t *testing.T, rot ili948x.Rotation, reverse bool, header, footer uint16) (*ili948x.Console, *ili948x.Ili948x, *sim.Display) {
+	t.Helper()
+	disp, d := newSimulated(t)
+	if err := disp.SetRotation(rot); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.SetScrollReverse(reverse); err != nil {
+		t.Fatal(err)
+	}
+	c, err := ili948x.NewConsole(disp, ili948x.Font7x13, 0xffffff, 0x000080, header, footer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, disp, d
+}
+
+func writeConsole(t *testing.T, c *ili948x.Console, s string) {
+	t.Helper()
+	if n, err := c.WriteString(s); err != nil || n != len(s) {
+		t.Fatalf("write %q: %d, %v", s, n, err)
+	}
+}
+
+func TestConsoleScrollModes(t *testing.T) {
+	for _, tt := range scrollModes {
+		c, _, d := newConsoleAt(t, tt.rot, tt.reverse, 13, 13)
+		cols, rows := c.Size()
+		// lines of different lengths, so text left in a reused row shows
+		var lines []string
+		for i := 0; i < rows+5; i++ {
+			lines = append(lines, "line "+strconv.Itoa(i)+strings.Repeat(".", i*7%(cols-8)))
+		}
+		writeConsole(t, c, strings.Join(lines, "\n"))
+
+		// the visible lines written without scrolling
+		ref, _, refd := newConsoleAt(t, tt.rot, tt.reverse, 13, 13)
+		writeConsole(t, ref, strings.Join(lines[len(lines)-rows:], "\n"))
+		checkImage(t, fmt.Sprint(tt), d.Image(), refd.Image())
+	}
+}
+
+// consoleImage returns the image of a console in Rot_0 after the writes.
+func consoleImage(t *testing.T, writes ...string) *image.RGBA {
+	t.Helper()
+	c, _, d := newConsoleAt(t, ili948x.Rot_0, false, 0, 0)
+	for _, s := range writes {
+		writeConsole(t, c, s)
+	}
+	return d.Image()
+}
+
+func TestConsoleControls(t *testing.T) {
+	cols := sim.Width / 7
+	a := strings.Repeat("a", cols)
+	tests := []struct {
+		name string
+		got  []string
+		want string
+	}{
+		{"carriage return", []string{"abc\rX"}, "Xbc"},
+		{"carriage return at the edge", []string{a + "\rX"}, "X" + a[1:]},
+		{"newline", []string{"ab\ncd"}, "ab\ncd"},
+		{"tab", []string{"ab\tc\td"}, "ab      c       d"},
+		{"tab at a stop", []string{"abcdefgh\tc"}, "abcdefgh        c"},
+		{"tab near the right edge", []string{a[3:] + "\tb"}, a[3:] + "   b"},
+		{"tab at the right edge", []string{a + "\tb"}, a + "b"},
+		{"backspace", []string{"abc\b\bX"}, "aXc"},
+		{"backspace at the left edge", []string{"\b\bX"}, "X"},
+		{"backspace after a wrap", []string{a + "b\b\bX"}, a + "X"},
+		{"wrap", []string{a + "bcd"}, a + "\nbcd"},
+		{"other controls", []string{"a\x00\x07\x1b\x7fb"}, "ab"},
+		{"utf-8 split", []string{"x\xc3", "\xa9y"}, "x\u00e9y"},
+		{"utf-8 in single bytes", []string{"\xf0", "\x9f", "\x98", "\x80", "z"}, "\U0001f600z"},
+	}
+	for _, tt := range tests {
+		checkImage(t, tt.name, consoleImage(t, tt.got...), consoleImage(t, tt.want))
+	}
+}
+
+func TestConsoleHeaderFooter(t *testing.T) {
+	const header, footer = 20, 30
+	for _, tt := range scrollModes {
+		disp, d := newSimulated(t)
+		if err := disp.SetRotation(tt.rot); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.SetScrollReverse(tt.reverse); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.FillScreen(0xff0000); err != nil {
+			t.Fatal(err)
+		}
+		before := d.Image()
+
+		c, err := ili948x.NewConsole(disp, ili948x.Font7x13, 0xffffff, 0x000080, header, footer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cols, rows := c.Size()
+		for i := 0; i < rows+5; i++ {
+			writeConsole(t, c, strings.Repeat("#", cols)+"\n")
+		}
+		if err := c.Clear(); err != nil {
+			t.Fatal(err)
+		}
+		writeConsole(t, c, strings.Repeat("#", cols*rows+3))
+		after := d.Image()
+
+		// the lines outside the scroll area, including those left over
+		// below the last row, keep their color
+		w, h := disp.Size()
+		bottom := header + rows*13
+		for y := 0; y < int(h); y++ {
+			if y == header {
+				y = bottom
+			}
+			for x := 0; x < int(w); x++ {
+				px, py := panelPoint(tt.rot, x, y)
+				if after.RGBAAt(px, py) != before.RGBAAt(px, py) {
+					t.Fatalf("%v: line %d changed", tt, y)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ili948x
+
+import (
+	"image/color"
+
+	"tinygo.org/x/drivers"
+)
+
+var _ drivers.Displayer = (*Displayer)(nil)
+
+// Displayer adapts an Ili948x to the tinygo.org/x/drivers Displayer interface
+// so tinydraw, tinyfont and tinyterm can draw on it. Pixels are written to the
+// display immediately, there is no frame buffer.
+//
+// SetPixel and SetScroll have no error result in the interface; their errors
+// are kept and returned by LastErr and Display.
+type Displayer struct {
+	disp *Ili948x
+	err  error // last error of a method without an error result
+}
+
+// NewDisplayer returns a Displayer drawing on disp.
+func NewDisplayer(disp *Ili948x) *Displayer {
+	return &Displayer{disp: disp}
+}
+
+// Size returns the current size of the display.
+func (d *Displayer) Size() (int16, int16) {
+	return d.disp.Size()
+}
+
+// SetPixel draws a single pixel, ignoring pixels outside the display.
+func (d *Displayer) SetPixel(x, y int16, c color.RGBA) {
+	d.record(d.disp.DrawPixel(x, y, RGBAToColor(c)))
+}
+
+// Display returns and clears the last error of SetPixel or SetScroll, pixels
+// are already drawn.
+func (d *Displayer) Display() error {
+	return d.LastErr()
+}
+
+// LastErr returns the last error of SetPixel or SetScroll since the previous
+// call and clears it.
+func (d *Displayer) LastErr() error {
+	err := d.err
+	d.err = nil
+	return err
+}
+
+// FillRectangle fills a rectangle with a single window write, clipped to the
+// display. tinyterm detects this method and uses it to clear character cells.
+func (d *Displayer) FillRectangle(x, y, width, height int16, c color.RGBA) error {
+	return d.disp.FillRectangle(x, y, width, height, RGBAToColor(c))
+}
+
+// SetScroll sets the vertical scroll address of the display, as used by tinyterm.
+func (d *Displayer) SetScroll(line int16) {
+	d.record(d.disp.SetScroll(uint16(line)))
+}
+
+// record keeps err, if any, for LastErr.
+func (d *Displayer) record(err error) {
+	if err != nil {
+		d.err = err
+	}
+}
+
+// RGBAToColor converts a color.RGBA to the 0xrrggbb color used by Ili948x,
+// ignoring alpha.
+func RGBAToColor(c color.RGBA) uint32 {
+	return uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
+}
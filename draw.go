@@ -0,0 +1,405 @@
+package ili948x
+
+import (
+	"math"
+)
+
+// Point is a position on the display.
+type Point struct {
+	X, Y int16
+}
+
+// span is a run of pixels on a row, from x0 to x1 inclusive.
+type span struct {
+	x0, x1 int32
+}
+
+// DrawLine draws a line from x0, y0 to x1, y1 with the specified color.
+// Horizontal and vertical lines are a single rectangle fill. Other lines have
+// the pixels of Bresenham's algorithm, the same whichever end the line starts
+// at, computed a run at a time: each run of pixels on the same row (or column,
+// for steep lines) is one window write.
+func (disp *Ili948x) DrawLine(x0, y0, x1, y1 int16, color uint32) error {
+	ax, ay, bx, by := int32(x0), int32(y0), int32(x1), int32(y1)
+	switch {
+	case ay == by:
+		return disp.fillRect(min32(ax, bx), ay, abs32(bx-ax)+1, 1, color)
+	case ax == bx:
+		return disp.fillRect(ax, min32(ay, by), 1, abs32(by-ay)+1, color)
+	}
+
+	dx, dy := abs32(bx-ax), abs32(by-ay)
+	// draw from the end with the lower major coordinate, so both directions
+	// round halfway pixels alike
+	if dx >= dy && ax > bx || dx < dy && ay > by {
+		ax, ay, bx, by = bx, by, ax, ay
+	}
+	sx, sy := sign32(bx-ax), sign32(by-ay)
+	if dx >= dy {
+		// horizontal runs, one per row
+		for k := int32(0); k <= dy; k++ {
+			lo, hi := lineRun(dx, dy, k)
+			xa, xb := ax+lo*sx, ax+hi*sx
+			if err := disp.fillRect(min32(xa, xb), ay+k*sy, hi-lo+1, 1, color); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// vertical runs, one per column
+	for k := int32(0); k <= dx; k++ {
+		lo, hi := lineRun(dy, dx, k)
+		ya, yb := ay+lo*sy, ay+hi*sy
+		if err := disp.fillRect(ax+k*sx, min32(ya, yb), 1, hi-lo+1, color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DrawCircle draws the outline of a circle centered at x, y with the
+// specified color.
+func (disp *Ili948x) DrawCircle(x, y, radius int16, color uint32) error {
+	return disp.DrawEllipse(x, y, radius, radius, color)
+}
+
+// FillCircle fills a circle centered at x, y with the specified color.
+func (disp *Ili948x) FillCircle(x, y, radius int16, color uint32) error {
+	return disp.FillEllipse(x, y, radius, radius, color)
+}
+
+// DrawEllipse draws the outline of an ellipse centered at x, y with radii rx
+// and ry with the specified color. The outline is drawn as horizontal spans,
+// up to four per row.
+func (disp *Ili948x) DrawEllipse(x, y, rx, ry int16, color uint32) error {
+	return disp.drawEllipse(int32(x), int32(y), int32(rx), int32(ry), nil, color)
+}
+
+// FillEllipse fills an ellipse centered at x, y with radii rx and ry with the
+// specified color, one span per row.
+func (disp *Ili948x) FillEllipse(x, y, rx, ry int16, color uint32) error {
+	cx, cy, a, b := int32(x), int32(y), int32(rx), int32(ry)
+	if a < 0 || b < 0 {
+		return nil
+	}
+	_, h := disp.Size()
+	for dy := int32(0); dy <= b; dy++ {
+		if cy-dy < 0 && cy+dy >= int32(h) {
+			break
+		}
+		w := ellipseHalfWidth(a, b, dy)
+		if err := disp.fillRect(cx-w, cy-dy, 2*w+1, 1, color); err != nil {
+			return err
+		}
+		if dy > 0 {
+			if err := disp.fillRect(cx-w, cy+dy, 2*w+1, 1, color); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DrawArc draws the part of the outline of a circle centered at x, y from
+// startAngle to endAngle with the specified color. Angles are in degrees,
+// clockwise from the positive x axis (3 o'clock). Angles 360 or more apart
+// draw the whole circle.
+func (disp *Ili948x) DrawArc(x, y, radius, startAngle, endAngle int16, color uint32) error {
+	sweep := int32(endAngle) - int32(startAngle)
+	if sweep >= 360 || sweep <= -360 {
+		return disp.DrawCircle(x, y, radius, color)
+	}
+	if sweep = (sweep%360 + 360) % 360; sweep == 0 {
+		return nil
+	}
+
+	// start and end directions, scaled by 1024
+	s, e := float64(startAngle)*math.Pi/180, float64(endAngle)*math.Pi/180
+	a := &arc{
+		sx:   int64(math.Round(math.Cos(s) * 1024)),
+		sy:   int64(math.Round(math.Sin(s) * 1024)),
+		ex:   int64(math.Round(math.Cos(e) * 1024)),
+		ey:   int64(math.Round(math.Sin(e) * 1024)),
+		wide: sweep > 180,
+	}
+	return disp.drawEllipse(int32(x), int32(y), int32(radius), int32(radius), a, color)
+}
+
+// DrawTriangle draws the outline of a triangle with the specified color.
+func (disp *Ili948x) DrawTriangle(x0, y0, x1, y1, x2, y2 int16, color uint32) error {
+	pts := [3]Point{{x0, y0}, {x1, y1}, {x2, y2}}
+	return disp.DrawPolygon(pts[:], color)
+}
+
+// FillTriangle fills a triangle with the specified color, including its
+// outline.
+func (disp *Ili948x) FillTriangle(x0, y0, x1, y1, x2, y2 int16, color uint32) error {
+	pts := [3]Point{{x0, y0}, {x1, y1}, {x2, y2}}
+	return disp.FillPolygon(pts[:], color)
+}
+
+// DrawPolygon draws the closed outline through points with the specified color.
+func (disp *Ili948x) DrawPolygon(points []Point, color uint32) error {
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		if err := disp.DrawLine(p.X, p.Y, q.X, q.Y, color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FillPolygon fills the polygon through points with the specified color,
+// including its outline as DrawPolygon draws it. Self-intersecting polygons
+// are filled by the even-odd rule. The polygon is filled a row at a time, each
+// span of the row being one window write.
+func (disp *Ili948x) FillPolygon(points []Point, color uint32) error {
+	if len(points) == 0 {
+		return nil
+	}
+	ymin, ymax := int32(points[0].Y), int32(points[0].Y)
+	for _, p := range points[1:] {
+		ymin, ymax = min32(ymin, int32(p.Y)), max32(ymax, int32(p.Y))
+	}
+	_, h := disp.Size()
+	ymin, ymax = max32(ymin, 0), min32(ymax, int32(h)-1)
+
+	for y := ymin; y <= ymax; y++ {
+		spans, xs := disp.polySpans[:0], disp.polyXs[:0]
+		for i, p := range points {
+			q := points[(i+1)%len(points)]
+			x0, y0, x1, y1 := int32(p.X), int32(p.Y), int32(q.X), int32(q.Y)
+			if y < min32(y0, y1) || y > max32(y0, y1) {
+				continue
+			}
+			// pixels of the edge
+			spans = append(spans, edgeSpan(x0, y0, x1, y1, y))
+			// crossing of the row, counting the upper end of edges only
+			if y0 > y1 {
+				x0, y0, x1, y1 = x1, y1, x0, y0
+			}
+			if y0 != y1 && y != y1 {
+				xs = append(xs, int64(x0)<<16+floorDiv64(int64(y-y0)*int64(x1-x0)<<16, int64(y1-y0)))
+			}
+		}
+
+		// interior between pairs of crossings
+		sortInt64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			xa, xb := int32(-floorDiv64(-xs[i], 1<<16)), int32(floorDiv64(xs[i+1], 1<<16))
+			if xa <= xb {
+				spans = append(spans, span{xa, xb})
+			}
+		}
+		disp.polySpans, disp.polyXs = spans, xs
+
+		sortSpans(spans)
+		cur := spans[0]
+		for _, s := range spans[1:] {
+			if s.x0 <= cur.x1+1 {
+				cur.x1 = max32(cur.x1, s.x1)
+				continue
+			}
+			if err := disp.fillRect(cur.x0, y, cur.x1-cur.x0+1, 1, color); err != nil {
+				return err
+			}
+			cur = s
+		}
+		if err := disp.fillRect(cur.x0, y, cur.x1-cur.x0+1, 1, color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// arc limits an outline to the directions clockwise from start to end, both
+// scaled by 1024. Wide arcs sweep more than 180 degrees.
+type arc struct {
+	sx, sy, ex, ey int64
+	wide           bool
+}
+
+// contains reports whether the direction x, y from the center is on the arc.
+func (a *arc) contains(x, y int64) bool {
+	fromStart := a.sx*y-a.sy*x >= 0
+	toEnd := x*a.ey-y*a.ex >= 0
+	if a.wide {
+		return fromStart || toEnd
+	}
+	return fromStart && toEnd
+}
+
+// drawEllipse draws the outline of an ellipse, or its part on the arc if on is
+// not nil.
+// Each row of the outline is the pixels beyond the half width of the next row
+// further from the center.
+func (disp *Ili948x) drawEllipse(cx, cy, a, b int32, on *arc, color uint32) error {
+	if a < 0 || b < 0 {
+		return nil
+	}
+	_, h := disp.Size()
+	outer := ellipseHalfWidth(a, b, 0)
+	for dy := int32(0); dy <= b; dy++ {
+		if cy-dy < 0 && cy+dy >= int32(h) {
+			break
+		}
+		next := ellipseHalfWidth(a, b, dy+1)
+		inner := min32(next+1, outer)
+		for _, y := range [2]int32{-dy, dy} {
+			var err error
+			if inner == 0 {
+				err = disp.arcSpan(cx, cy, -outer, outer, y, on, color)
+			} else if err = disp.arcSpan(cx, cy, -outer, -inner, y, on, color); err == nil {
+				err = disp.arcSpan(cx, cy, inner, outer, y, on, color)
+			}
+			if err != nil {
+				return err
+			}
+			if dy == 0 {
+				break
+			}
+		}
+		outer = next
+	}
+	return nil
+}
+
+// arcSpan draws the pixels x0 to x1 of row y, relative to the center cx, cy,
+// which are on a, or all of them if a is nil.
+func (disp *Ili948x) arcSpan(cx, cy, x0, x1, y int32, a *arc, color uint32) error {
+	if a == nil {
+		return disp.fillRect(cx+x0, cy+y, x1-x0+1, 1, color)
+	}
+	run := x0
+	for x := x0; x <= x1+1; x++ {
+		if x <= x1 && a.contains(int64(x), int64(y)) {
+			continue
+		}
+		if x > run {
+			if err := disp.fillRect(cx+run, cy+y, x-run, 1, color); err != nil {
+				return err
+			}
+		}
+		run = x + 1
+	}
+	return nil
+}
+
+// fillRect fills the visible part of a rectangle.
+func (disp *Ili948x) fillRect(x, y, width, height int32, color uint32) error {
+	cx, cy, cw, ch, ok := disp.clip(x, y, width, height)
+	if !ok {
+		return nil
+	}
+	return disp.fillWindow(cx, cy, cw, ch, color)
+}
+
+// lineRun returns the first and last of the 0 to d major steps of a Bresenham
+// line which are on minor step k of n.
+func lineRun(d, n, k int32) (int32, int32) {
+	h := int64(d / 2)
+	lo := floorDiv64(int64(k-1)*int64(d)+h, int64(n)) + 1
+	hi := floorDiv64(int64(k)*int64(d)+h, int64(n))
+	return int32(max64(lo, 0)), int32(min64(hi, int64(d)))
+}
+
+// edgeSpan returns the pixels on row y of the line DrawLine draws from x0, y0
+// to x1, y1.
+func edgeSpan(x0, y0, x1, y1, y int32) span {
+	dx, dy := abs32(x1-x0), abs32(y1-y0)
+	if dx >= dy && x0 > x1 || dx < dy && y0 > y1 {
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+	sx, k := sign32(x1-x0), abs32(y-y0)
+	switch {
+	case dy == 0:
+		return span{min32(x0, x1), max32(x0, x1)}
+	case dx >= dy:
+		lo, hi := lineRun(dx, dy, k)
+		xa, xb := x0+lo*sx, x0+hi*sx
+		return span{min32(xa, xb), max32(xa, xb)}
+	}
+	// the column of major step k
+	m := -floorDiv64(-(int64(k)*int64(dx) - int64(dy/2)), int64(dy))
+	x := x0 + int32(max64(m, 0))*sx
+	return span{x, x}
+}
+
+// ellipseHalfWidth returns the half width of row dy from the center of an
+// ellipse with radii a and b, -1 beyond the ellipse. Rows cover the pixels
+// with their centers inside the ellipse with radii a+½ and b+½, for a circle
+// the pixels of the midpoint circle algorithm.
+func ellipseHalfWidth(a, b, dy int32) int32 {
+	if dy > b {
+		return -1
+	}
+	ra, rb, d := uint64(2*a+1), uint64(2*b+1), uint64(2*dy)
+	return int32(isqrt(ra * ra * (rb*rb - d*d) / (4 * rb * rb)))
+}
+
+// isqrt returns the integer square root of n.
+func isqrt(n uint64) uint64 {
+	r := uint64(math.Sqrt(float64(n)))
+	for r*r > n {
+		r--
+	}
+	for r < math.MaxUint32 && (r+1)*(r+1) <= n {
+		r++
+	}
+	return r
+}
+
+// sortSpans sorts a few spans by their start, in place.
+func sortSpans(s []span) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j].x0 < s[j-1].x0; j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+		}
+	}
+}
+
+// sortInt64s sorts a few values, in place.
+func sortInt64s(v []int64) {
+	for i := 1; i < len(v); i++ {
+		for j := i; j > 0 && v[j] < v[j-1]; j-- {
+			v[j], v[j-1] = v[j-1], v[j]
+		}
+	}
+}
+
+// floorDiv64 returns a / b rounded down, for b > 0.
+func floorDiv64(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign32(v int32) int32 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
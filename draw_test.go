@@ -0,0 +1,434 @@
+package ili948x_test
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/sim"
+	"github.com/inindev/ili948x/trace"
+)
+
+// pixelSet is the set of lit pixels of a drawing.
+type pixelSet map[image.Point]bool
+
+// drawn clears the screen, runs draw and returns the pixels it lit.
+func drawn(t *testing.T, disp *ili948x.Ili948x, d *sim.Display, draw func() error) pixelSet {
+	t.Helper()
+	if err := disp.FillScreen(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := draw(); err != nil {
+		t.Fatal(err)
+	}
+	lit := pixelSet{}
+	for y := 0; y < sim.Height; y++ {
+		for x := 0; x < sim.Width; x++ {
+			if d.Pixel(x, y) != 0 {
+				lit[image.Pt(x, y)] = true
+			}
+		}
+	}
+	return lit
+}
+
+// checkSet compares two pixel sets.
+func checkSet(t *testing.T, name string, got, want pixelSet) {
+	t.Helper()
+	for p := range want {
+		if !got[p] {
+			t.Errorf("%s: %v not lit", name, p)
+			return
+		}
+	}
+	for p := range got {
+		if !want[p] {
+			t.Errorf("%s: %v lit", name, p)
+			return
+		}
+	}
+}
+
+// union returns the pixels lit in any of the sets.
+func union(sets ...pixelSet) pixelSet {
+	u := pixelSet{}
+	for _, s := range sets {
+		for p := range s {
+			u[p] = true
+		}
+	}
+	return u
+}
+
+func TestDrawLine(t *testing.T) {
+	disp, d := newSimulated(t)
+	const ax, ay = 160, 240
+	// every octant, the diagonals and lines with halfway pixels
+	ends := [][2]int{
+		{40, 15}, {15, 40}, {-15, 40}, {-40, 15},
+		{-40, -15}, {-15, -40}, {15, -40}, {40, -15},
+		{30, 30}, {-30, 30}, {4, 2}, {-2, 4}, {-4, -2}, {2, -4},
+		{7, 3}, {-3, -7}, {1, 1},
+	}
+	for _, e := range ends {
+		dx, dy := e[0], e[1]
+		bx, by := ax+dx, ay+dy
+		fwd := drawn(t, disp, d, func() error {
+			return disp.DrawLine(ax, ay, int16(bx), int16(by), 0xffffff)
+		})
+		rev := drawn(t, disp, d, func() error {
+			return disp.DrawLine(int16(bx), int16(by), ax, ay, 0xffffff)
+		})
+		name := image.Pt(dx, dy).String()
+		checkSet(t, name+" reversed", rev, fwd)
+
+		if !fwd[image.Pt(ax, ay)] || !fwd[image.Pt(bx, by)] {
+			t.Errorf("%s: endpoints not lit", name)
+		}
+		major, minor := abs(dx), abs(dy)
+		if major < minor {
+			major, minor = minor, major
+		}
+		if len(fwd) != major+1 {
+			t.Errorf("%s: %d pixels lit, want %d", name, len(fwd), major+1)
+		}
+		// one pixel per step along the major axis, at most half a pixel off
+		// the ideal line
+		if abs(dx) < abs(dy) {
+			dx, dy = dy, dx
+		}
+		for p := range fwd {
+			px, py := p.X-ax, p.Y-ay
+			if abs(e[0]) < abs(e[1]) {
+				px, py = py, px
+			}
+			if 2*abs(py*dx-px*dy) > abs(dx) {
+				t.Errorf("%s: %v off the line", name, p)
+			}
+		}
+	}
+}
+
+// inside reports whether x, y is inside the polygon by the even-odd rule.
+// Points on an edge may be reported either way.
+func inside(pts []ili948x.Point, x, y int) bool {
+	in := false
+	for i, p := range pts {
+		q := pts[(i+1)%len(pts)]
+		x0, y0, x1, y1 := int(p.X), int(p.Y), int(q.X), int(q.Y)
+		if y0 > y1 {
+			x0, y0, x1, y1 = x1, y1, x0, y0
+		}
+		if y < y0 || y >= y1 {
+			continue
+		}
+		// crossing right of x
+		if x0*(y1-y0)+(y-y0)*(x1-x0) > x*(y1-y0) {
+			in = !in
+		}
+	}
+	return in
+}
+
+// star returns the points of a pentagram centered at x, y.
+func star(x, y, r int) []ili948x.Point {
+	pts := make([]ili948x.Point, 5)
+	for i := range pts {
+		a := (-90 + 144*float64(i)) * math.Pi / 180
+		pts[i] = ili948x.Point{
+			X: int16(x + int(math.Round(float64(r)*math.Cos(a)))),
+			Y: int16(y + int(math.Round(float64(r)*math.Sin(a)))),
+		}
+	}
+	return pts
+}
+
+func TestFillPolygon(t *testing.T) {
+	disp, d := newSimulated(t)
+	tests := []struct {
+		name  string
+		pts   []ili948x.Point
+		empty []image.Point // inside the outline but not filled
+	}{
+		{name: "triangle", pts: []ili948x.Point{{20, 30}, {150, 60}, {60, 200}}},
+		{name: "thin", pts: []ili948x.Point{{200, 10}, {210, 150}, {190, 90}}},
+		{name: "flat", pts: []ili948x.Point{{10, 300}, {100, 300}, {50, 400}}},
+		{name: "flat bottom", pts: []ili948x.Point{{250, 300}, {300, 420}, {200, 420}}},
+		{
+			name:  "concave",
+			pts:   []ili948x.Point{{160, 250}, {300, 300}, {160, 350}, {200, 300}},
+			empty: []image.Point{{180, 300}, {165, 300}},
+		},
+		{
+			name:  "bow tie",
+			pts:   []ili948x.Point{{20, 250}, {120, 350}, {120, 250}, {20, 350}},
+			empty: []image.Point{{70, 260}, {70, 340}},
+		},
+		{
+			name:  "pentagram",
+			pts:   star(240, 400, 70),
+			empty: []image.Point{{240, 400}},
+		},
+	}
+	for _, tt := range tests {
+		outline := drawn(t, disp, d, func() error { return disp.DrawPolygon(tt.pts, 0xffffff) })
+		fill := drawn(t, disp, d, func() error { return disp.FillPolygon(tt.pts, 0xffffff) })
+
+		want := pixelSet{}
+		for p := range outline {
+			want[p] = true
+		}
+		for y := 0; y < sim.Height; y++ {
+			for x := 0; x < sim.Width; x++ {
+				if inside(tt.pts, x, y) {
+					want[image.Pt(x, y)] = true
+				}
+			}
+		}
+		checkSet(t, tt.name, fill, want)
+		for _, p := range tt.empty {
+			if fill[p] {
+				t.Errorf("%s: %v filled", tt.name, p)
+			}
+		}
+
+		if len(tt.pts) == 3 {
+			p := tt.pts
+			tri := drawn(t, disp, d, func() error {
+				return disp.FillTriangle(p[0].X, p[0].Y, p[1].X, p[1].Y, p[2].X, p[2].Y, 0xffffff)
+			})
+			checkSet(t, tt.name+" FillTriangle", tri, fill)
+			tri = drawn(t, disp, d, func() error {
+				return disp.DrawTriangle(p[0].X, p[0].Y, p[1].X, p[1].Y, p[2].X, p[2].Y, 0xffffff)
+			})
+			checkSet(t, tt.name+" DrawTriangle", tri, outline)
+		}
+	}
+}
+
+func TestDrawArc(t *testing.T) {
+	disp, d := newSimulated(t)
+	const cx, cy, r = 160, 240, 60
+	arc := func(start, end int16) pixelSet {
+		return drawn(t, disp, d, func() error { return disp.DrawArc(cx, cy, r, start, end, 0xffffff) })
+	}
+	circle := drawn(t, disp, d, func() error { return disp.DrawCircle(cx, cy, r, 0xffffff) })
+
+	// clockwise from 3 o'clock, with y down
+	var quads []pixelSet
+	for i, q := range []struct {
+		sx, sy int       // signs of the quadrant
+		ends   [2][2]int // both ends of the arc
+	}{
+		{1, 1, [2][2]int{{r, 0}, {0, r}}},
+		{-1, 1, [2][2]int{{0, r}, {-r, 0}}},
+		{-1, -1, [2][2]int{{-r, 0}, {0, -r}}},
+		{1, -1, [2][2]int{{0, -r}, {r, 0}}},
+	} {
+		lit := arc(int16(90*i), int16(90*i+90))
+		for p := range lit {
+			if (p.X-cx)*q.sx < 0 || (p.Y-cy)*q.sy < 0 {
+				t.Errorf("quadrant %d: %v lit", i, p)
+				break
+			}
+		}
+		for _, e := range q.ends {
+			if !lit[image.Pt(cx+e[0], cy+e[1])] {
+				t.Errorf("quadrant %d: end %v not lit", i, e)
+			}
+		}
+		quads = append(quads, lit)
+	}
+	checkSet(t, "quadrants", union(quads...), circle)
+
+	// wraparound through 0 degrees
+	wrap := arc(350, 10)
+	checkSet(t, "-10..10", arc(-10, 10), wrap)
+	checkSet(t, "350..360 0..10", union(arc(350, 360), arc(0, 10)), wrap)
+	if !wrap[image.Pt(cx+r, cy)] {
+		t.Errorf("350..10: 3 o'clock not lit")
+	}
+	for p := range wrap {
+		a := math.Atan2(float64(p.Y-cy), float64(p.X-cx)) * 180 / math.Pi
+		if math.Abs(a) > 11 {
+			t.Errorf("350..10: %v at %.1f degrees lit", p, a)
+			break
+		}
+	}
+	long := arc(10, 350)
+	if long[image.Pt(cx+r, cy)] {
+		t.Errorf("10..350: 3 o'clock lit")
+	}
+	checkSet(t, "10..350 350..10", union(long, wrap), circle)
+
+	checkSet(t, "0..360", arc(0, 360), circle)
+	checkSet(t, "90..-270", arc(90, -270), circle)
+	if lit := arc(30, 30); len(lit) != 0 {
+		t.Errorf("30..30: %d pixels lit", len(lit))
+	}
+	if lit := arc(30, 390); len(lit) != len(circle) {
+		t.Errorf("30..390: %d pixels lit, want %d", len(lit), len(circle))
+	}
+}
+
+func TestDrawClipped(t *testing.T) {
+	disp, d := newSimulated(t)
+	shapes := []struct {
+		name string
+		draw func(disp *ili948x.Ili948x, x, y int16) error
+	}{
+		{"DrawLine", func(disp *ili948x.Ili948x, x, y int16) error { return disp.DrawLine(x-40, y-25, x+70, y+30, 0xffffff) }},
+		{"DrawLine steep", func(disp *ili948x.Ili948x, x, y int16) error { return disp.DrawLine(x+10, y-60, x-15, y+50, 0xffffff) }},
+		{"DrawCircle", func(disp *ili948x.Ili948x, x, y int16) error { return disp.DrawCircle(x, y, 30, 0xffffff) }},
+		{"FillCircle", func(disp *ili948x.Ili948x, x, y int16) error { return disp.FillCircle(x, y, 30, 0xffffff) }},
+		{"FillEllipse", func(disp *ili948x.Ili948x, x, y int16) error { return disp.FillEllipse(x, y, 40, 20, 0xffffff) }},
+		{"DrawArc", func(disp *ili948x.Ili948x, x, y int16) error { return disp.DrawArc(x, y, 30, 200, 340, 0xffffff) }},
+		{"FillPolygon", func(disp *ili948x.Ili948x, x, y int16) error {
+			return disp.FillPolygon(star(int(x), int(y), 50), 0xffffff)
+		}},
+	}
+	const rx, ry = 160, 240
+	for _, s := range shapes {
+		ref := drawn(t, disp, d, func() error { return s.draw(disp, rx, ry) })
+		for _, at := range []image.Point{{0, 0}, {-20, 240}, {160, -30}, {319, 479}, {340, 240}, {160, 500}} {
+			got := drawn(t, disp, d, func() error { return s.draw(disp, int16(at.X), int16(at.Y)) })
+			want := pixelSet{}
+			for p := range ref {
+				if q := p.Add(at).Sub(image.Pt(rx, ry)); q.In(image.Rect(0, 0, sim.Width, sim.Height)) {
+					want[q] = true
+				}
+			}
+			checkSet(t, s.name+" at "+at.String(), got, want)
+		}
+		// far off the screen nothing is written
+		rdisp, rec := newRecorded(t)
+		if err := s.draw(rdisp, -200, -200); err != nil {
+			t.Fatal(err)
+		}
+		if ops := rec.Ops(); len(ops) != 0 {
+			t.Errorf("%s off the screen: %d ops", s.name, len(ops))
+		}
+	}
+
+	// lines through the whole coordinate range
+	lit := drawn(t, disp, d, func() error { return disp.DrawLine(-32768, -32768, 32767, 32767, 0xffffff) })
+	want := pixelSet{}
+	for i := 0; i < sim.Width; i++ {
+		want[image.Pt(i, i)] = true
+	}
+	checkSet(t, "diagonal", lit, want)
+	lit = drawn(t, disp, d, func() error {
+		return disp.FillTriangle(-32768, 0, 32767, -32768, 32767, 32767, 0xffffff)
+	})
+	if len(lit) != sim.Width*sim.Height {
+		t.Errorf("FillTriangle: %d pixels lit, want the whole screen", len(lit))
+	}
+	lit = drawn(t, disp, d, func() error {
+		return disp.FillPolygon([]ili948x.Point{{-32768, -32768}, {32767, -32768}, {32767, 32767}, {-32768, 32767}}, 0xffffff)
+	})
+	if len(lit) != sim.Width*sim.Height {
+		t.Errorf("FillPolygon: %d pixels lit, want the whole screen", len(lit))
+	}
+}
+
+// spanWindows returns the address windows of the recorded memory writes,
+// failing if a write is more than one row or its window was set more than once.
+func spanWindows(t *testing.T, ops []trace.Op) []image.Rectangle {
+	t.Helper()
+	var wins []image.Rectangle
+	var win image.Rectangle
+	var caset, paset bool
+	for _, op := range ops {
+		switch op.Cmd {
+		case ili948x.CMD_CASET:
+			if caset {
+				t.Fatalf("CASET twice before RAMWR")
+			}
+			caset = true
+			win.Min.X, win.Max.X = be16(op.Params), be16(op.Params[2:])+1
+		case ili948x.CMD_PASET:
+			if paset {
+				t.Fatalf("PASET twice before RAMWR")
+			}
+			paset = true
+			win.Min.Y, win.Max.Y = be16(op.Params), be16(op.Params[2:])+1
+		case ili948x.CMD_RAMWR:
+			if win.Dy() != 1 {
+				t.Fatalf("window %v is not one row", win)
+			}
+			if len(op.Params) != 3*win.Dx() {
+				t.Fatalf("window %v written with %d bytes", win, len(op.Params))
+			}
+			wins = append(wins, win)
+			caset, paset = false, false
+		default:
+			t.Fatalf("unexpected command %#02x", op.Cmd)
+		}
+	}
+	return wins
+}
+
+func be16(b []uint8) int {
+	return int(b[0])<<8 | int(b[1])
+}
+
+func TestFillSpans(t *testing.T) {
+	disp, d := newSimulated(t)
+	tests := []struct {
+		name   string
+		convex bool
+		draw   func(disp *ili948x.Ili948x) error
+	}{
+		{"FillTriangle", true, func(disp *ili948x.Ili948x) error {
+			return disp.FillTriangle(20, 30, 150, 60, 60, 200, 0xffffff)
+		}},
+		{"FillCircle", true, func(disp *ili948x.Ili948x) error { return disp.FillCircle(160, 240, 50, 0xffffff) }},
+		{"FillEllipse", true, func(disp *ili948x.Ili948x) error { return disp.FillEllipse(160, 240, 70, 20, 0xffffff) }},
+		{"FillPolygon concave", false, func(disp *ili948x.Ili948x) error {
+			return disp.FillPolygon([]ili948x.Point{{160, 250}, {300, 300}, {160, 350}, {200, 300}}, 0xffffff)
+		}},
+		{"FillPolygon pentagram", false, func(disp *ili948x.Ili948x) error {
+			return disp.FillPolygon(star(160, 240, 100), 0xffffff)
+		}},
+	}
+	for _, tt := range tests {
+		lit := drawn(t, disp, d, func() error { return tt.draw(disp) })
+
+		rdisp, rec := newRecorded(t)
+		if err := tt.draw(rdisp); err != nil {
+			t.Fatal(err)
+		}
+		wins := spanWindows(t, rec.Ops())
+
+		// the windows cover the filled pixels once, a window per span
+		got := pixelSet{}
+		rows := map[int][]image.Rectangle{}
+		for _, w := range wins {
+			for x := w.Min.X; x < w.Max.X; x++ {
+				p := image.Pt(x, w.Min.Y)
+				if got[p] {
+					t.Fatalf("%s: %v written twice", tt.name, p)
+				}
+				got[p] = true
+			}
+			for _, o := range rows[w.Min.Y] {
+				if w.Min.X <= o.Max.X && o.Min.X <= w.Max.X {
+					t.Errorf("%s: windows %v and %v are one span", tt.name, o, w)
+				}
+			}
+			rows[w.Min.Y] = append(rows[w.Min.Y], w)
+		}
+		checkSet(t, tt.name, got, lit)
+		if tt.convex && len(rows) != len(wins) {
+			t.Errorf("%s: %d windows for %d rows", tt.name, len(wins), len(rows))
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
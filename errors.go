@@ -0,0 +1,48 @@
+package ili948x
+
+import (
+	"errors"
+)
+
+var (
+	ErrBus          = errors.New("bus error")
+	ErrOutOfBounds  = errors.New("coordinates outside display area")
+	ErrNotSupported = errors.New("operation not supported")
+	ErrFormat       = errors.New("invalid data format")
+	ErrAsleep       = errors.New("display is asleep")
+	ErrTimeout      = errors.New("timeout")
+
+	ErrUnknownController = errors.New("unknown display controller")
+)
+
+// Error describes a failed display operation. errors.Is matches it against
+// its Kind, one of the Err* sentinels, and it unwraps to the underlying cause.
+type Error struct {
+	Op   string // failed operation
+	Kind error  // ErrBus, ErrOutOfBounds, ...
+	Err  error  // underlying cause, may be nil
+}
+
+func (e *Error) Error() string {
+	msg := "ili948x: " + e.Op + ": " + e.Kind.Error()
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// busError wraps a transport error as ErrBus, returning nil for a nil error.
+func busError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Op: op, Kind: ErrBus, Err: err}
+}
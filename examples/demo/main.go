@@ -1,3 +1,5 @@
+//go:build tinygo
+
 package main
 
 import (
@@ -5,6 +7,8 @@ import (
 	"os"
 	"time"
 
+	"github.com/inindev/ili948x"
+
 	"tinygo.org/x/drivers/sdcard"
 	"tinygo.org/x/tinyfs/fatfs"
 )
@@ -49,7 +53,7 @@ var (
 )
 
 func main() {
-	machine.SPI2.Configure(machine.SPIConfig{
+	spiConfig := machine.SPIConfig{
 		SCK: machine.TFT_SCK_PIN,
 		SDO: machine.TFT_SDO_PIN,
 		SDI: machine.TFT_SDI_PIN,
@@ -57,53 +61,68 @@ func main() {
 		LSBFirst:  false,
 		Mode:      machine.SPI_MODE0,
 		Frequency: 40e6,
-	})
-
-	disp := NewIli9488(
-		NewSPITransport(machine.SPI2),
-		machine.TFT_CS_PIN, // chip select
-		machine.TFT_DC_PIN, // data / command
-		machine.TFT_BL_PIN, // backlight
-		machine.NoPin,      // reset
-		TFT_DEFAULT_WIDTH,
-		TFT_DEFAULT_HEIGHT)
+	}
+	machine.SPI2.Configure(spiConfig)
+
+	// read the controller id to pick the ILI9488 or ILI9486 init sequence
+	disp, err := ili948x.New(
+		ili948x.NewSPIReadTransport(machine.SPI2, spiConfig, 6e6),
+		ili948x.OutputPin(machine.TFT_CS_PIN), // chip select
+		ili948x.OutputPin(machine.TFT_DC_PIN), // data / command
+		ili948x.OutputPin(machine.TFT_BL_PIN), // backlight
+		nil,                                   // reset
+		ili948x.TFT_DEFAULT_WIDTH,
+		ili948x.TFT_DEFAULT_HEIGHT)
+	if err != nil {
+		printError("failed to initialize display", "", err)
+		return
+	}
 
-	//disp.screenFillDemo(ryb_colors)
-	disp.screenFillDemo(cmy_colors)
+	//screenFillDemo(disp, ryb_colors)
+	screenFillDemo(disp, cmy_colors)
 
-	//disp.quadrantDemo()
-	disp.rotateDemo(disp.quadrantDemo, 1500, 4)
+	//quadrantDemo(disp)
+	rotateDemo(disp, quadrantDemo, 1500, 4)
 
-	//disp.colorBlocksDemo()
-	disp.rotateDemo(disp.colorBlocksDemo, 500, 8)
+	//colorBlocksDemo(disp)
+	rotateDemo(disp, colorBlocksDemo, 500, 8)
 
-	//disp.stackedRectanglesDemo()
-	disp.rotateDemo(disp.stackedRectanglesDemo, 1500, 6)
+	//stackedRectanglesDemo(disp)
+	rotateDemo(disp, stackedRectanglesDemo, 1500, 6)
 
-	disp.SetRotation(Rot_90)
-	disp.bitmapDemo("/logo.bmp")
+	disp.SetRotation(ili948x.Rot_90)
+	bitmapDemo(disp, "/logo.bmp")
 	time.Sleep(time.Second)
 
-	disp.SetRotation(Rot_270)
-	disp.bitmapDemo("/logo.bmp")
+	disp.SetRotation(ili948x.Rot_270)
+	bitmapDemo(disp, "/logo.bmp")
 
-	// scroll demo
-	tfa := uint16(15)
-	bfa := uint16(160)
-	disp.SetScrollArea(tfa, bfa)
+	// scroll demo, scrolls left and right in Rot_270
+	disp.SetScrollArea(15, 160)
+	tfa, vsa, _ := disp.GetScrollArea()
 
-	for i := tfa + 1; i < 480-bfa; i++ {
+	for i := tfa + 1; i < tfa+vsa; i++ {
 		disp.SetScroll(i)
 		time.Sleep(time.Millisecond * 25)
 	}
 	time.Sleep(time.Second)
 
-	for i := 480 - bfa - 1; i >= tfa; i-- {
+	for i := tfa + vsa - 1; i >= tfa; i-- {
 		disp.SetScroll(i)
 		time.Sleep(time.Millisecond * 25)
 	}
 	time.Sleep(time.Second)
 
+	// the same lines scroll the other way when reversed
+	disp.SetScrollReverse(true)
+	for i := tfa + 1; i < tfa+vsa; i++ {
+		disp.SetScroll(i)
+		time.Sleep(time.Millisecond * 25)
+	}
+	time.Sleep(time.Second)
+
+	disp.SetScroll(tfa)
+	disp.SetScrollReverse(false)
 	disp.StopScroll()
 
 	for {
@@ -111,14 +130,14 @@ func main() {
 	}
 }
 
-func (disp *Ili948x) screenFillDemo(palette []uint32) {
+func screenFillDemo(disp *ili948x.Ili948x, palette []uint32) {
 	for _, color := range palette {
 		disp.FillScreen(color)
 		time.Sleep(time.Millisecond * 1000)
 	}
 }
 
-func (disp *Ili948x) quadrantDemo() {
+func quadrantDemo(disp *ili948x.Ili948x) {
 	cfa := []uint32{RYB_BGREEN, RYB_BPURPLE}
 	cba := []uint32{RYB_YORANGE, RYB_YGREEN}
 
@@ -130,7 +149,7 @@ func (disp *Ili948x) quadrantDemo() {
 	disp.DrawVLine(width/2, 10, height-20, cfa[i%2])
 }
 
-func (disp *Ili948x) colorBlocksDemo() {
+func colorBlocksDemo(disp *ili948x.Ili948x) {
 	palette := [10][10]uint32{
 		{0xfdedec, 0xfadbd8, 0xf5b7b1, 0xf1948a, 0xec7063, 0xe74c3c, 0xcb4335, 0xb03a2e, 0x943126, 0x78281f}, // reds
 		{0xf4ecf7, 0xe8daef, 0xd2b4de, 0xbb8fce, 0xa569bd, 0x8e44ad, 0x7d3c98, 0x6c3483, 0x5b2c6f, 0x4a235a}, // purples
@@ -145,16 +164,16 @@ func (disp *Ili948x) colorBlocksDemo() {
 	}
 
 	width, height := disp.Size()
-	bw := width / uint16(10)
-	bh := height / uint16(10)
-	for x := uint16(0); x < 10; x++ {
-		for y := uint16(0); y < 10; y++ {
+	bw := width / 10
+	bh := height / 10
+	for x := int16(0); x < 10; x++ {
+		for y := int16(0); y < 10; y++ {
 			disp.FillRectangle(x*bw, y*bh, bw, bh, palette[x][y])
 		}
 	}
 }
 
-func (disp *Ili948x) stackedRectanglesDemo() {
+func stackedRectanglesDemo(disp *ili948x.Ili948x) {
 	const (
 		G_RED uint32 = 0xea4335
 
@@ -173,7 +192,7 @@ func (disp *Ili948x) stackedRectanglesDemo() {
 	disp.FillRectangle(width/4, height/4, width/2, height/2, CMT) // middle
 }
 
-func (disp *Ili948x) bitmapDemo(filename string) {
+func bitmapDemo(disp *ili948x.Ili948x, filename string) {
 	sd := sdcard.New(&machine.SPI2, machine.SD_SCK_PIN, machine.SD_SDO_PIN, machine.SD_SDI_PIN, machine.SD_CS_PIN)
 	err := sd.Configure()
 	if err != nil {
@@ -193,32 +212,16 @@ func (disp *Ili948x) bitmapDemo(filename string) {
 	}
 	defer f.Close()
 
-	// https://en.wikipedia.org/wiki/BMP_file_format
-	header := make([]byte, 14)
-	f.Read(header)
-	//print(hex.Dump(header))
-	if header[0] != 'B' || header[1] != 'M' {
-		printError("bitmap file is invalid", "", err)
-		return
-	}
-	img_offs := uint32(header[10]) | uint32(header[11])<<8 | uint32(header[12])<<16 | uint32(header[13])<<24
-
-	// reuse buffer to read past remaining header
-	q := img_offs / uint32(len(header))
-	for i := uint32(1); i < q; i++ {
-		f.Read(header)
+	err = disp.DrawBMP(0, 0, f)
+	if err != nil {
+		printError("failed to display bitmap", filename, err)
 	}
-	r := img_offs % uint32(len(header))
-	f.Read(header[:r])
-
-	width, height := disp.Size()
-	disp.DisplayBitmap(0, 0, width, height, 24, f)
 }
 
-func (disp *Ili948x) rotateDemo(pfunc func(), delayMs time.Duration, count int) {
+func rotateDemo(disp *ili948x.Ili948x, pfunc func(*ili948x.Ili948x), delayMs time.Duration, count int) {
 	for i := 0; i < count; i++ {
-		disp.SetRotation(Rotation(i % 4))
-		pfunc()
+		disp.SetRotation(ili948x.Rotation(i % 4))
+		pfunc(disp)
 		time.Sleep(time.Millisecond * delayMs)
 	}
 }
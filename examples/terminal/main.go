@@ -0,0 +1,63 @@
+//go:build tinygo
+
+package main
+
+import (
+	"machine"
+
+	"github.com/inindev/ili948x"
+)
+
+// terminal renders the serial console on the display, e.g. run a shell on the
+// host with its output sent to the device's serial port
+func main() {
+	spiConfig := machine.SPIConfig{
+		SCK:       machine.TFT_SCK_PIN,
+		SDO:       machine.TFT_SDO_PIN,
+		SDI:       machine.TFT_SDI_PIN,
+		LSBFirst:  false,
+		Mode:      machine.SPI_MODE0,
+		Frequency: 40e6,
+	}
+	machine.SPI2.Configure(spiConfig)
+
+	disp, err := ili948x.New(
+		ili948x.NewSPIReadTransport(machine.SPI2, spiConfig, 6e6),
+		ili948x.OutputPin(machine.TFT_CS_PIN), // chip select
+		ili948x.OutputPin(machine.TFT_DC_PIN), // data / command
+		ili948x.OutputPin(machine.TFT_BL_PIN), // backlight
+		nil,                                   // reset
+		ili948x.TFT_DEFAULT_WIDTH,
+		ili948x.TFT_DEFAULT_HEIGHT)
+	if err != nil {
+		println("failed to initialize display - error:", err.Error())
+		return
+	}
+
+	term, err := ili948x.NewTerminal(disp, ili948x.Font7x13)
+	if err != nil {
+		println("failed to create terminal - error:", err.Error())
+		return
+	}
+
+	if err := term.Run(serialReader{}); err != nil {
+		println("terminal stopped - error:", err.Error())
+	}
+}
+
+// serialReader reads the bytes buffered by machine.Serial, returning none
+// when there are none.
+type serialReader struct{}
+
+func (serialReader) Read(b []uint8) (int, error) {
+	n := 0
+	for n < len(b) && machine.Serial.Buffered() > 0 {
+		c, err := machine.Serial.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		b[n] = c
+		n++
+	}
+	return n, nil
+}
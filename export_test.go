@@ -0,0 +1,27 @@
+package ili948x
+
+// test hooks for the external test package
+
+const (
+	TermBold      = termBold
+	TermUnderline = termUnderline
+	TermInverse   = termInverse
+)
+
+var XtermColor = xtermColor
+
+// Cell returns the character and attributes of a screen buffer cell.
+func (t *Terminal) Cell(col, row int) (r rune, fg, bg uint32, flags uint8) {
+	c := t.cells[row*t.cols+col]
+	return c.r, c.a.fg, c.a.bg, c.a.flags
+}
+
+// Cursor returns the cursor position.
+func (t *Terminal) Cursor() (col, row int) {
+	return t.col, t.row
+}
+
+// Region returns the scroll region rows, inclusive.
+func (t *Terminal) Region() (top, bottom int) {
+	return t.top, t.bottom
+}
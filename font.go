@@ -0,0 +1,110 @@
+package ili948x
+
+import (
+	"sort"
+)
+
+// Font is a bitmap font in a compact format: a table of glyph metrics and the
+// glyph bitmaps, trimmed to their ink and packed BPP bits per pixel, row by
+// row and most significant bits first. Each glyph bitmap starts on a byte
+// boundary. Fonts with 2, 4 or 8 bits per pixel hold the coverage (alpha) of
+// anti-aliased glyphs, which are blended between the text colors. Fonts are
+// generated with cmd/fontconv.
+type Font struct {
+	Height uint8       // line height
+	Ascent uint8       // distance from the top of a line to the baseline
+	BPP    uint8       // bits per pixel: 1 (or 0), 2, 4 or 8
+	Ranges []FontRange // rune ranges in increasing order
+	Glyphs []Glyph
+	Bitmap []uint8
+}
+
+// FontRange maps the runes First to Last to consecutive glyphs.
+type FontRange struct {
+	First, Last rune
+	Glyph       uint16 // index of the glyph of First
+}
+
+// Glyph holds the metrics of a glyph and the offset of its bitmap.
+type Glyph struct {
+	Offset  uint32 // start of the bitmap in Font.Bitmap
+	Width   uint8  // bitmap width
+	Height  uint8  // bitmap height
+	XOffset int8   // from the pen position to the left of the bitmap
+	YOffset int8   // from the baseline to the top of the bitmap
+	Advance uint8  // pen advance
+}
+
+// Glyph returns the glyph of r, falling back to U+FFFD and '?' for runes the
+// font does not cover. nil is returned if there is no fallback either.
+func (f *Font) Glyph(r rune) *Glyph {
+	for _, c := range [3]rune{r, 0xfffd, '?'} {
+		if g := f.lookup(c); g != nil {
+			return g
+		}
+	}
+	return nil
+}
+
+// lookup returns the glyph of r, or nil if the font does not cover it.
+func (f *Font) lookup(r rune) *Glyph {
+	i := sort.Search(len(f.Ranges), func(i int) bool {
+		return f.Ranges[i].Last >= r
+	})
+	if i == len(f.Ranges) || f.Ranges[i].First > r {
+		return nil
+	}
+	return &f.Glyphs[int(f.Ranges[i].Glyph)+int(r-f.Ranges[i].First)]
+}
+
+// alpha returns the coverage of pixel x, y of the bitmap of g, 0 to 255.
+func (f *Font) alpha(g *Glyph, x, y int) uint8 {
+	bpp := int(f.BPP)
+	if bpp == 0 {
+		bpp = 1
+	}
+	i := (y*int(g.Width) + x) * bpp
+	v := f.Bitmap[int(g.Offset)+i/8] >> (8 - bpp - i%8) & (1<<bpp - 1)
+	return uint8(int(v) * 255 / (1<<bpp - 1))
+}
+
+// blend mixes colors fg and bg by alpha a, 0 being bg and 255 fg.
+func blend(fg, bg uint32, a uint8) uint32 {
+	switch a {
+	case 0:
+		return bg
+	case 0xff:
+		return fg
+	}
+	var c uint32
+	for shift := 0; shift < 24; shift += 8 {
+		f, b := fg>>shift&0xff, bg>>shift&0xff
+		c |= (f*uint32(a) + b*uint32(0xff-a) + 0x7f) / 0xff << shift
+	}
+	return c
+}
+
+// renderCell renders g into a width * height cell of colors, with the pen at
+// the left of the cell and the baseline at the font ascent. Pixels outside
+// the cell are clipped.
+func (f *Font) renderCell(cell []uint32, width, height int, g *Glyph, fg, bg uint32) {
+	for i := range cell[:width*height] {
+		cell[i] = bg
+	}
+	if g == nil {
+		return
+	}
+	x0, y0 := int(g.XOffset), int(f.Ascent)+int(g.YOffset)
+	for y := 0; y < int(g.Height); y++ {
+		cy := y0 + y
+		if cy < 0 || cy >= height {
+			continue
+		}
+		for x := 0; x < int(g.Width); x++ {
+			cx := x0 + x
+			if cx >= 0 && cx < width {
+				cell[cy*width+cx] = blend(fg, cell[cy*width+cx], f.alpha(g, x, y))
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package ili948x
+
+// Font7x13 is the 7x13 X11 misc-fixed font, which is in the public domain,
+// with the printable ASCII characters and U+FFFD.
+var Font7x13 = &Font{
+	Height: 13,
+	Ascent: 11,
+	Ranges: []FontRange{
+		{First: 0x20, Last: 0x7e, Glyph: 0},
+		{First: 0xfffd, Last: 0xfffd, Glyph: 95},
+	},
+	Glyphs: []Glyph{
+		{Offset: 0, Width: 0, Height: 0, XOffset: 0, YOffset: 0, Advance: 7},      // ' '
+		{Offset: 0, Width: 1, Height: 9, XOffset: 3, YOffset: -9, Advance: 7},     // '!'
+		{Offset: 2, Width: 3, Height: 3, XOffset: 2, YOffset: -9, Advance: 7},     // '"'
+		{Offset: 4, Width: 5, Height: 7, XOffset: 1, YOffset: -8, Advance: 7},     // '#'
+		{Offset: 9, Width: 5, Height: 7, XOffset: 1, YOffset: -8, Advance: 7},     // '$'
+		{Offset: 14, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},    // '%'
+		{Offset: 21, Width: 6, Height: 7, XOffset: 0, YOffset: -7, Advance: 7},    // '&'
+		{Offset: 27, Width: 1, Height: 3, XOffset: 3, YOffset: -9, Advance: 7},    // '\''
+		{Offset: 28, Width: 3, Height: 9, XOffset: 2, YOffset: -9, Advance: 7},    // '('
+		{Offset: 32, Width: 3, Height: 9, XOffset: 2, YOffset: -9, Advance: 7},    // ')'
+		{Offset: 36, Width: 6, Height: 5, XOffset: 0, YOffset: -7, Advance: 7},    // '*'
+		{Offset: 40, Width: 5, Height: 5, XOffset: 1, YOffset: -7, Advance: 7},    // '+'
+		{Offset: 44, Width: 4, Height: 3, XOffset: 1, YOffset: -2, Advance: 7},    // ','
+		{Offset: 46, Width: 5, Height: 1, XOffset: 1, YOffset: -5, Advance: 7},    // '-'
+		{Offset: 47, Width: 3, Height: 3, XOffset: 2, YOffset: -2, Advance: 7},    // '.'
+		{Offset: 49, Width: 5, Height: 9, XOffset: 1, YOffset: -9, Advance: 7},    // '/'
+		{Offset: 55, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},    // '0'
+		{Offset: 62, Width: 5, Height: 9, XOffset: 1, YOffset: -9, Advance: 7},    // '1'
+		{Offset: 68, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},    // '2'
+		{Offset: 75, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},    // '3'
+		{Offset: 82, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},    // '4'
+		{Offset: 89, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},    // '5'
+		{Offset: 96, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},    // '6'
+		{Offset: 103, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // '7'
+		{Offset: 110, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // '8'
+		{Offset: 117, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // '9'
+		{Offset: 124, Width: 3, Height: 8, XOffset: 2, YOffset: -7, Advance: 7},   // ':'
+		{Offset: 127, Width: 4, Height: 8, XOffset: 1, YOffset: -7, Advance: 7},   // ';'
+		{Offset: 131, Width: 5, Height: 9, XOffset: 1, YOffset: -9, Advance: 7},   // '<'
+		{Offset: 137, Width: 6, Height: 4, XOffset: 0, YOffset: -6, Advance: 7},   // '='
+		{Offset: 140, Width: 5, Height: 9, XOffset: 1, YOffset: -9, Advance: 7},   // '>'
+		{Offset: 146, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // '?'
+		{Offset: 153, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // '@'
+		{Offset: 160, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'A'
+		{Offset: 167, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'B'
+		{Offset: 174, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'C'
+		{Offset: 181, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'D'
+		{Offset: 188, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'E'
+		{Offset: 195, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'F'
+		{Offset: 202, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'G'
+		{Offset: 209, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'H'
+		{Offset: 216, Width: 5, Height: 9, XOffset: 1, YOffset: -9, Advance: 7},   // 'I'
+		{Offset: 222, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'J'
+		{Offset: 229, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'K'
+		{Offset: 236, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'L'
+		{Offset: 243, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'M'
+		{Offset: 250, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'N'
+		{Offset: 257, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'O'
+		{Offset: 264, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'P'
+		{Offset: 271, Width: 6, Height: 10, XOffset: 0, YOffset: -9, Advance: 7},  // 'Q'
+		{Offset: 279, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'R'
+		{Offset: 286, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'S'
+		{Offset: 293, Width: 5, Height: 9, XOffset: 1, YOffset: -9, Advance: 7},   // 'T'
+		{Offset: 299, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'U'
+		{Offset: 306, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'V'
+		{Offset: 313, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'W'
+		{Offset: 320, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'X'
+		{Offset: 327, Width: 5, Height: 9, XOffset: 1, YOffset: -9, Advance: 7},   // 'Y'
+		{Offset: 333, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'Z'
+		{Offset: 340, Width: 4, Height: 11, XOffset: 1, YOffset: -10, Advance: 7}, // '['
+		{Offset: 346, Width: 5, Height: 9, XOffset: 1, YOffset: -9, Advance: 7},   // '\\'
+		{Offset: 352, Width: 4, Height: 11, XOffset: 1, YOffset: -10, Advance: 7}, // ']'
+		{Offset: 358, Width: 5, Height: 3, XOffset: 1, YOffset: -9, Advance: 7},   // '^'
+		{Offset: 360, Width: 6, Height: 1, XOffset: 0, YOffset: 0, Advance: 7},    // '_'
+		{Offset: 361, Width: 2, Height: 2, XOffset: 2, YOffset: -10, Advance: 7},  // '`'
+		{Offset: 362, Width: 6, Height: 6, XOffset: 0, YOffset: -6, Advance: 7},   // 'a'
+		{Offset: 367, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'b'
+		{Offset: 374, Width: 6, Height: 6, XOffset: 0, YOffset: -6, Advance: 7},   // 'c'
+		{Offset: 379, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'd'
+		{Offset: 386, Width: 6, Height: 6, XOffset: 0, YOffset: -6, Advance: 7},   // 'e'
+		{Offset: 391, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'f'
+		{Offset: 398, Width: 6, Height: 8, XOffset: 0, YOffset: -6, Advance: 7},   // 'g'
+		{Offset: 404, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'h'
+		{Offset: 411, Width: 5, Height: 8, XOffset: 1, YOffset: -8, Advance: 7},   // 'i'
+		{Offset: 416, Width: 5, Height: 10, XOffset: 1, YOffset: -8, Advance: 7},  // 'j'
+		{Offset: 423, Width: 6, Height: 9, XOffset: 0, YOffset: -9, Advance: 7},   // 'k'
+		{Offset: 430, Width: 5, Height: 9, XOffset: 1, YOffset: -9, Advance: 7},   // 'l'
+		{Offset: 436, Width: 5, Height: 6, XOffset: 1, YOffset: -6, Advance: 7},   // 'm'
+		{Offset: 440, Width: 6, Height: 6, XOffset: 0, YOffset: -6, Advance: 7},   // 'n'
+		{Offset: 445, Width: 6, Height: 6, XOffset: 0, YOffset: -6, Advance: 7},   // 'o'
+		{Offset: 450, Width: 6, Height: 8, XOffset: 0, YOffset: -6, Advance: 7},   // 'p'
+		{Offset: 456, Width: 6, Height: 8, XOffset: 0, YOffset: -6, Advance: 7},   // 'q'
+		{Offset: 462, Width: 6, Height: 6, XOffset: 0, YOffset: -6, Advance: 7},   // 'r'
+		{Offset: 467, Width: 6, Height: 6, XOffset: 0, YOffset: -6, Advance: 7},   // 's'
+		{Offset: 472, Width: 6, Height: 8, XOffset: 0, YOffset: -8, Advance: 7},   // 't'
+		{Offset: 478, Width: 6, Height: 6, XOffset: 0, YOffset: -6, Advance: 7},   // 'u'
+		{Offset: 483, Width: 5, Height: 6, XOffset: 1, YOffset: -6, Advance: 7},   // 'v'
+		{Offset: 487, Width: 5, Height: 6, XOffset: 1, YOffset: -6, Advance: 7},   // 'w'
+		{Offset: 491, Width: 6, Height: 6, XOffset: 0, YOffset: -6, Advance: 7},   // 'x'
+		{Offset: 496, Width: 6, Height: 8, XOffset: 0, YOffset: -6, Advance: 7},   // 'y'
+		{Offset: 502, Width: 6, Height: 6, XOffset: 0, YOffset: -6, Advance: 7},   // 'z'
+		{Offset: 507, Width: 5, Height: 11, XOffset: 1, YOffset: -10, Advance: 7}, // '{'
+		{Offset: 514, Width: 1, Height: 9, XOffset: 3, YOffset: -9, Advance: 7},   // '|'
+		{Offset: 516, Width: 5, Height: 11, XOffset: 1, YOffset: -10, Advance: 7}, // '}'
+		{Offset: 523, Width: 5, Height: 3, XOffset: 1, YOffset: -9, Advance: 7},   // '~'
+		{Offset: 525, Width: 5, Height: 9, XOffset: 1, YOffset: -9, Advance: 7},   // U+FFFD
+	},
+	Bitmap: []uint8{
+		0xfe, 0x80, // '!'
+		0xb6, 0x80, // '"'
+		0x52, 0xbe, 0xaf, 0xa9, 0x40, // '#'
+		0x23, 0xe8, 0xe2, 0xf8, 0x80, // '$'
+		0x46, 0x94, 0x84, 0x10, 0x84, 0xa5, 0x88, // '%'
+		0x62, 0x49, 0x18, 0x96, 0x27, 0x40, // '&'
+		0xe0,                   // '\''
+		0x29, 0x49, 0x12, 0x20, // '('
+		0x89, 0x12, 0x52, 0x80, // ')'
+		0x48, 0xcf, 0xcc, 0x48, // '*'
+		0x21, 0x3e, 0x42, 0x00, // '+'
+		0x76, 0x80, // ','
+		0xf8,       // '-'
+		0x5d, 0x00, // '.'
+		0x08, 0x44, 0x22, 0x21, 0x10, 0x80, // '/'
+		0x31, 0x28, 0x61, 0x86, 0x18, 0x52, 0x30, // '0'
+		0x23, 0x28, 0x42, 0x10, 0x84, 0xf8, // '1'
+		0x7a, 0x18, 0x41, 0x08, 0xc4, 0x20, 0xfc, // '2'
+		0xfc, 0x10, 0x84, 0x38, 0x10, 0x61, 0x78, // '3'
+		0x08, 0x62, 0x92, 0x8a, 0x2f, 0xc2, 0x08, // '4'
+		0xfe, 0x08, 0x2e, 0xc4, 0x10, 0x61, 0x78, // '5'
+		0x39, 0x08, 0x20, 0xbb, 0x18, 0x61, 0x78, // '6'
+		0xfc, 0x10, 0x84, 0x10, 0x82, 0x10, 0x40, // '7'
+		0x7a, 0x18, 0x61, 0x7a, 0x18, 0x61, 0x78, // '8'
+		0x7a, 0x18, 0x63, 0x74, 0x10, 0x42, 0x70, // '9'
+		0x5d, 0x00, 0xba, // ':'
+		0x27, 0x20, 0x07, 0x68, // ';'
+		0x08, 0x88, 0x88, 0x20, 0x82, 0x08, // '<'
+		0xfc, 0x00, 0x3f, // '='
+		0x82, 0x08, 0x20, 0x88, 0x88, 0x80, // '>'
+		0x7a, 0x18, 0x41, 0x08, 0x41, 0x00, 0x10, // '?'
+		0x7a, 0x18, 0x67, 0xa6, 0xb9, 0x60, 0x78, // '@'
+		0x31, 0x28, 0x61, 0x87, 0xf8, 0x61, 0x84, // 'A'
+		0xf9, 0x14, 0x51, 0x79, 0x14, 0x51, 0xf8, // 'B'
+		0x7a, 0x18, 0x20, 0x82, 0x08, 0x21, 0x78, // 'C'
+		0xf9, 0x14, 0x51, 0x45, 0x14, 0x51, 0xf8, // 'D'
+		0xfe, 0x08, 0x20, 0xf2, 0x08, 0x20, 0xfc, // 'E'
+		0xfe, 0x08, 0x20, 0xf2, 0x08, 0x20, 0x80, // 'F'
+		0x7a, 0x18, 0x20, 0x82, 0x78, 0x63, 0x74, // 'G'
+		0x86, 0x18, 0x61, 0xfe, 0x18, 0x61, 0x84, // 'H'
+		0xf9, 0x08, 0x42, 0x10, 0x84, 0xf8, // 'I'
+		0x1c, 0x20, 0x82, 0x08, 0x20, 0xa2, 0x70, // 'J'
+		0x86, 0x29, 0x28, 0xc2, 0x89, 0x22, 0x84, // 'K'
+		0x82, 0x08, 0x20, 0x82, 0x08, 0x20, 0xfc, // 'L'
+		0x87, 0x3c, 0xed, 0xb6, 0x18, 0x61, 0x84, // 'M'
+		0x86, 0x1c, 0x69, 0x96, 0x38, 0x61, 0x84, // 'N'
+		0x7a, 0x18, 0x61, 0x86, 0x18, 0x61, 0x78, // 'O'
+		0xfa, 0x18, 0x61, 0xfa, 0x08, 0x20, 0x80, // 'P'
+		0x7a, 0x18, 0x61, 0x86, 0x1a, 0x65, 0x78, 0x10, // 'Q'
+		0xfa, 0x18, 0x61, 0xfa, 0x89, 0x22, 0x84, // 'R'
+		0x7a, 0x18, 0x20, 0x78, 0x10, 0x61, 0x78, // 'S'
+		0xf9, 0x08, 0x42, 0x10, 0x84, 0x20, // 'T'
+		0x86, 0x18, 0x61, 0x86, 0x18, 0x61, 0x78, // 'U'
+		0x86, 0x18, 0x52, 0x49, 0x23, 0x0c, 0x30, // 'V'
+		0x86, 0x18, 0x61, 0xb6, 0xdc, 0xf3, 0x84, // 'W'
+		0x86, 0x14, 0x92, 0x31, 0x24, 0xa1, 0x84, // 'X'
+		0x8c, 0x54, 0xa2, 0x10, 0x84, 0x20, // 'Y'
+		0xfc, 0x10, 0x84, 0x30, 0x84, 0x20, 0xfc, // 'Z'
+		0xf8, 0x88, 0x88, 0x88, 0x88, 0xf0, // '['
+		0x84, 0x10, 0x82, 0x08, 0x41, 0x08, // '\\'
+		0xf1, 0x11, 0x11, 0x11, 0x11, 0xf0, // ']'
+		0x22, 0xa2, // '^'
+		0xfc,                         // '_'
+		0x90,                         // '`'
+		0x78, 0x17, 0xe1, 0x8d, 0xd0, // 'a'
+		0x82, 0x08, 0x2e, 0xc6, 0x18, 0x71, 0xb8, // 'b'
+		0x7a, 0x18, 0x20, 0x85, 0xe0, // 'c'
+		0x04, 0x10, 0x5d, 0x8e, 0x18, 0x63, 0x74, // 'd'
+		0x7a, 0x1f, 0xe0, 0x85, 0xe0, // 'e'
+		0x39, 0x14, 0x10, 0xf1, 0x04, 0x10, 0x40, // 'f'
+		0x76, 0x28, 0x9c, 0x81, 0xe8, 0x5e, // 'g'
+		0x82, 0x08, 0x2e, 0xc6, 0x18, 0x61, 0x84, // 'h'
+		0x20, 0x18, 0x42, 0x10, 0x9f, // 'i'
+		0x08, 0x06, 0x10, 0x84, 0x31, 0x8b, 0x80, // 'j'
+		0x82, 0x08, 0x22, 0x93, 0x89, 0x22, 0x84, // 'k'
+		0x61, 0x08, 0x42, 0x10, 0x84, 0xf8, // 'l'
+		0xd5, 0x6b, 0x5a, 0xc4, // 'm'
+		0xbb, 0x18, 0x61, 0x86, 0x10, // 'n'
+		0x7a, 0x18, 0x61, 0x85, 0xe0, // 'o'
+		0xbb, 0x18, 0x71, 0xba, 0x08, 0x20, // 'p'
+		0x76, 0x38, 0x63, 0x74, 0x10, 0x41, // 'q'
+		0xb9, 0x14, 0x10, 0x41, 0x00, // 'r'
+		0x7a, 0x16, 0x06, 0x85, 0xe0, // 's'
+		0x41, 0x0f, 0x10, 0x41, 0x04, 0x4e, // 't'
+		0x86, 0x18, 0x61, 0x8d, 0xd0, // 'u'
+		0x8c, 0x62, 0xa5, 0x10, // 'v'
+		0x8c, 0x6b, 0x5a, 0xa8, // 'w'
+		0x85, 0x23, 0x0c, 0x4a, 0x10, // 'x'
+		0x86, 0x18, 0x63, 0x74, 0x18, 0x5e, // 'y'
+		0xfc, 0x21, 0x08, 0x43, 0xf0, // 'z'
+		0x3a, 0x10, 0x82, 0x60, 0x88, 0x42, 0x0e, // '{'
+		0xff, 0x80, // '|'
+		0xe0, 0x84, 0x22, 0x0c, 0x82, 0x10, 0xb8, // '}'
+		0x4d, 0x64, // '~'
+		0x76, 0xeb, 0xdd, 0xef, 0xfb, 0x70, // U+FFFD
+	},
+}
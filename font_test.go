@@ -0,0 +1,88 @@
+package ili948x
+
+import "testing"
+
+func TestBlend(t *testing.T) {
+	for _, tt := range []struct {
+		fg, bg uint32
+		a      uint8
+		want   uint32
+	}{
+		{0xffffff, 0x204060, 0x00, 0x204060},
+		{0xffffff, 0x204060, 0xff, 0xffffff},
+		{0xff0000, 0x0000ff, 0x80, 0x80007f},
+		{0xffffff, 0x204060, 0x40, 0x587088},
+		{0x000000, 0xc08040, 0xc0, 0x2f2010},
+		{0x123456, 0x654321, 0x01, 0x654321},
+		{0x123456, 0x654321, 0xfe, 0x123456},
+	} {
+		if got := blend(tt.fg, tt.bg, tt.a); got != tt.want {
+			t.Errorf("blend(%#06x, %#06x, %#02x) = %#06x, want %#06x", tt.fg, tt.bg, tt.a, got, tt.want)
+		}
+	}
+}
+
+// packGlyph appends the values, bpp bits each, to bitmap as Font packs a glyph
+// bitmap.
+func packGlyph(bitmap []uint8, bpp int, values []uint8) []uint8 {
+	var acc, n int
+	for _, v := range values {
+		acc = acc<<bpp | int(v)
+		if n += bpp; n == 8 {
+			bitmap = append(bitmap, uint8(acc))
+			acc, n = 0, 0
+		}
+	}
+	if n > 0 {
+		bitmap = append(bitmap, uint8(acc<<(8-n)))
+	}
+	return bitmap
+}
+
+func TestFontAlpha(t *testing.T) {
+	for _, bpp := range []uint8{0, 1, 2, 4, 8} {
+		bits := int(bpp)
+		if bits == 0 {
+			bits = 1
+		}
+		max := 1<<bits - 1
+
+		// a 3x3 glyph after a padding byte, its rows not byte aligned
+		values := make([]uint8, 9)
+		for i := range values {
+			values[i] = uint8((i*37 + 1) % (max + 1))
+		}
+		values[0], values[8] = 0, uint8(max)
+		f := &Font{BPP: bpp, Bitmap: packGlyph([]uint8{0xff}, bits, values)}
+		g := &Glyph{Offset: 1, Width: 3, Height: 3}
+
+		for i, v := range values {
+			want := uint8(int(v) * 255 / max)
+			if got := f.alpha(g, i%3, i/3); got != want {
+				t.Errorf("%d bpp: alpha(%d, %d) = %d, want %d", bpp, i%3, i/3, got, want)
+			}
+		}
+		if a := f.alpha(g, 0, 0); a != 0 {
+			t.Errorf("%d bpp: blank alpha %d", bpp, a)
+		}
+		if a := f.alpha(g, 2, 2); a != 0xff {
+			t.Errorf("%d bpp: full alpha %d", bpp, a)
+		}
+	}
+
+	// the levels of each depth are evenly spread
+	for _, tt := range []struct {
+		bpp   uint8
+		value uint8
+		want  uint8
+	}{
+		{2, 1, 85}, {2, 2, 170},
+		{4, 1, 17}, {4, 8, 136}, {4, 14, 238},
+		{8, 1, 1}, {8, 0x80, 0x80}, {8, 0xfe, 0xfe},
+	} {
+		f := &Font{BPP: tt.bpp, Bitmap: packGlyph(nil, int(tt.bpp), []uint8{tt.value})}
+		if got := f.alpha(&Glyph{Width: 1, Height: 1}, 0, 0); got != tt.want {
+			t.Errorf("%d bpp: alpha of %d = %d, want %d", tt.bpp, tt.value, got, tt.want)
+		}
+	}
+}
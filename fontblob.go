@@ -0,0 +1,101 @@
+package ili948x
+
+// Font blob layout, little endian:
+//
+//	magic      "ILF1"
+//	header     height, ascent, bpp, 0 (u8), ranges, glyphs (u16), bitmap size (u32)
+//	ranges     first, last (u32), glyph (u16)
+//	glyphs     offset (u32), width, height (u8), x offset, y offset (i8), advance (u8)
+//	bitmap
+const (
+	fontMagic      = "ILF1"
+	fontHeaderSize = 16
+	fontRangeSize  = 10
+	fontGlyphSize  = 9
+)
+
+// ParseFont decodes a font blob written by cmd/fontconv, e.g. embedded with
+// go:embed or mapped from flash. The bitmap of the font refers to data, which
+// must not be modified while the font is in use.
+func ParseFont(data []uint8) (*Font, error) {
+	const op = "ParseFont"
+	if len(data) < fontHeaderSize || string(data[:4]) != fontMagic {
+		return nil, &Error{Op: op, Kind: ErrFormat}
+	}
+	f := &Font{Height: data[4], Ascent: data[5], BPP: data[6]}
+	switch f.BPP {
+	case 1, 2, 4, 8:
+	default:
+		return nil, &Error{Op: op, Kind: ErrNotSupported}
+	}
+	nranges, nglyphs := int(le16(data[8:])), int(le16(data[10:]))
+	size := uint64(le32(data[12:]))
+	offs := fontHeaderSize + nranges*fontRangeSize + nglyphs*fontGlyphSize
+	if uint64(len(data)) != uint64(offs)+size {
+		return nil, &Error{Op: op, Kind: ErrFormat}
+	}
+
+	b := data[fontHeaderSize:]
+	f.Ranges = make([]FontRange, nranges)
+	for i := range f.Ranges {
+		r := FontRange{First: rune(le32(b)), Last: rune(le32(b[4:])), Glyph: le16(b[8:])}
+		// runes are int32, the range length is computed wide so it cannot wrap
+		if r.First < 0 || r.Last < r.First || int64(r.Glyph)+int64(r.Last)-int64(r.First) >= int64(nglyphs) ||
+			(i > 0 && r.First <= f.Ranges[i-1].Last) {
+			return nil, &Error{Op: op, Kind: ErrFormat}
+		}
+		f.Ranges[i] = r
+		b = b[fontRangeSize:]
+	}
+	f.Glyphs = make([]Glyph, nglyphs)
+	for i := range f.Glyphs {
+		g := Glyph{
+			Offset:  le32(b),
+			Width:   b[4],
+			Height:  b[5],
+			XOffset: int8(b[6]),
+			YOffset: int8(b[7]),
+			Advance: b[8],
+		}
+		bits := uint64(g.Width) * uint64(g.Height) * uint64(f.BPP)
+		if uint64(g.Offset)+(bits+7)/8 > size {
+			return nil, &Error{Op: op, Kind: ErrFormat}
+		}
+		f.Glyphs[i] = g
+		b = b[fontGlyphSize:]
+	}
+	f.Bitmap = b
+	return f, nil
+}
+
+// MarshalBinary encodes the font as a blob ParseFont decodes.
+func (f *Font) MarshalBinary() ([]uint8, error) {
+	if len(f.Ranges) > 0xffff || len(f.Glyphs) > 0xffff || uint64(len(f.Bitmap)) > 0xffffffff {
+		return nil, &Error{Op: "MarshalBinary", Kind: ErrOutOfBounds}
+	}
+	bpp := f.BPP
+	if bpp == 0 {
+		bpp = 1
+	}
+	n := fontHeaderSize + len(f.Ranges)*fontRangeSize + len(f.Glyphs)*fontGlyphSize
+	b := make([]uint8, n, n+len(f.Bitmap))
+	copy(b, fontMagic)
+	b[4], b[5], b[6] = f.Height, f.Ascent, bpp
+	putLE16(b[8:], uint16(len(f.Ranges)))
+	putLE16(b[10:], uint16(len(f.Glyphs)))
+	putLE32(b[12:], uint32(len(f.Bitmap)))
+
+	p := b[fontHeaderSize:]
+	for _, r := range f.Ranges {
+		putLE32(p, uint32(r.First))
+		putLE32(p[4:], uint32(r.Last))
+		putLE16(p[8:], r.Glyph)
+		p = p[fontRangeSize:]
+	}
+	for _, g := range f.Glyphs {
+		putLE32(p, g.Offset)
+		p[4], p[5], p[6], p[7], p[8] = g.Width, g.Height, uint8(g.XOffset), uint8(g.YOffset), g.Advance
+		p = p[fontGlyphSize:]
+	}
+	return append(b, f.Bitmap...), nil
+}
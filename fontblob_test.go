@@ -0,0 +1,141 @@
+package ili948x_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/inindev/ili948x"
+)
+
+// testFont is a 2 bpp font with two ranges.
+var testFont = &ili948x.Font{
+	Height: 4,
+	Ascent: 3,
+	BPP:    2,
+	Ranges: []ili948x.FontRange{
+		{First: 'A', Last: 'B', Glyph: 0},
+		{First: 0x1f600, Last: 0x1f600, Glyph: 2},
+	},
+	Glyphs: []ili948x.Glyph{
+		{Offset: 0, Width: 2, Height: 3, XOffset: -1, YOffset: 3, Advance: 3},
+		{Offset: 2, Width: 3, Height: 2, XOffset: 0, YOffset: 2, Advance: 4},
+		{Offset: 4, Width: 0, Height: 0, XOffset: 0, YOffset: 0, Advance: 5},
+	},
+	Bitmap: []uint8{0x1b, 0xe4, 0xff, 0x80},
+}
+
+func TestFontRoundTrip(t *testing.T) {
+	for _, f := range []*ili948x.Font{testFont, ili948x.Font7x13} {
+		blob, err := f.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ili948x.ParseFont(blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := *f
+		if want.BPP == 0 {
+			want.BPP = 1 // written explicitly
+		}
+		if !reflect.DeepEqual(got, &want) {
+			t.Errorf("got %+v, want %+v", got, &want)
+		}
+		again, err := got.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(again, blob) {
+			t.Errorf("blob changed after a round trip")
+		}
+	}
+}
+
+func TestParseFontErrors(t *testing.T) {
+	blob, err := testFont.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const ranges = 16 // offset of the ranges
+	const glyphs = ranges + 2*10
+
+	// patch returns a copy of the blob with a little endian value at offs
+	patch := func(offs int, v uint32, size int) []uint8 {
+		b := append([]uint8(nil), blob...)
+		if size == 2 {
+			binary.LittleEndian.PutUint16(b[offs:], uint16(v))
+		} else {
+			binary.LittleEndian.PutUint32(b[offs:], v)
+		}
+		return b
+	}
+	badMagic := append([]uint8(nil), blob...)
+	badMagic[3] = '2'
+	badBPP := append([]uint8(nil), blob...)
+	badBPP[6] = 3
+
+	for _, tt := range []struct {
+		name string
+		data []uint8
+		kind error
+	}{
+		{"empty", nil, ili948x.ErrFormat},
+		{"header", blob[:15], ili948x.ErrFormat},
+		{"magic", badMagic, ili948x.ErrFormat},
+		{"bpp", badBPP, ili948x.ErrNotSupported},
+		{"truncated", blob[:len(blob)-1], ili948x.ErrFormat},
+		{"trailing data", append(append([]uint8(nil), blob...), 0), ili948x.ErrFormat},
+		{"range count", patch(8, 3, 2), ili948x.ErrFormat},
+		{"bitmap size", patch(12, 0xffffffff, 4), ili948x.ErrFormat},
+		{"reversed range", patch(ranges, 'C', 4), ili948x.ErrFormat},
+		{"negative first", patch(ranges, 0x80000000, 4), ili948x.ErrFormat},
+		{"range length", patch(ranges+4, 0x7fffffff, 4), ili948x.ErrFormat},
+		{"glyph index", patch(ranges+10+8, 3, 2), ili948x.ErrFormat},
+		{"overlapping ranges", patch(ranges+10, 'B', 4), ili948x.ErrFormat},
+		{"glyph bitmap", patch(glyphs+9, 3, 4), ili948x.ErrFormat},
+	} {
+		if _, err := ili948x.ParseFont(tt.data); !errors.Is(err, tt.kind) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.kind)
+		}
+	}
+}
+
+func FuzzParseFont(f *testing.F) {
+	for _, font := range []*ili948x.Font{testFont, ili948x.Font7x13} {
+		blob, err := font.MarshalBinary()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(blob)
+	}
+	f.Fuzz(func(t *testing.T, data []uint8) {
+		font, err := ili948x.ParseFont(data)
+		if err != nil {
+			return
+		}
+		// the first and last runes of every range have a glyph
+		for _, r := range font.Ranges {
+			for _, c := range []rune{r.First, r.Last} {
+				if font.Glyph(c) == nil {
+					t.Fatalf("no glyph for %U", c)
+				}
+			}
+		}
+		ili948x.MeasureText("A\U0001f600�", font)
+		blob, err := font.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the reserved header byte is not kept
+		again, err := ili948x.ParseFont(blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(again, font) {
+			t.Errorf("got %+v after a round trip, want %+v", again, font)
+		}
+	})
+}
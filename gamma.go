@@ -0,0 +1,129 @@
+package ili948x
+
+import (
+	"math"
+)
+
+// Gamma holds the coefficients of the CMD_PGAMCTRL and CMD_NGAMCTRL gamma
+// correction tables, in the order the controller takes them. The positive
+// table runs from the V63 to the V0 reference level, the negative table from
+// V0 to V63.
+type Gamma struct {
+	Positive [15]uint8
+	Negative [15]uint8
+}
+
+// Gamma presets.
+var (
+	// GammaILI9488 is the reference curve of the ILI9488 application notes,
+	// close to a gamma of 2.2.
+	GammaILI9488 = Gamma{
+		Positive: [15]uint8{
+			0x00, 0x07, 0x0f, 0x0d, 0x1b, 0x0a, 0x3c, 0x78,
+			0x4a, 0x07, 0x0e, 0x09, 0x1b, 0x1e, 0x0f,
+		},
+		Negative: [15]uint8{
+			0x00, 0x22, 0x24, 0x06, 0x12, 0x07, 0x36, 0x47,
+			0x47, 0x06, 0x0a, 0x07, 0x30, 0x37, 0x0f,
+		},
+	}
+
+	// GammaMakerfabsESP32C3 is the curve of the Makerfabs ESP32-C3 3.5" module.
+	GammaMakerfabsESP32C3 = Gamma{
+		Positive: [15]uint8{
+			0x00, 0x03, 0x09, 0x08, 0x16, 0x0a, 0x3f, 0x78,
+			0x4c, 0x09, 0x0a, 0x08, 0x16, 0x1a, 0x0f,
+		},
+		Negative: [15]uint8{
+			0x00, 0x16, 0x19, 0x03, 0x0f, 0x05, 0x32, 0x45,
+			0x46, 0x04, 0x0e, 0x0d, 0x35, 0x37, 0x0f,
+		},
+	}
+
+	// GammaWaveshareILI9486 is the curve of the Waveshare 3.5" ILI9486 modules.
+	GammaWaveshareILI9486 = Gamma{
+		Positive: [15]uint8{
+			0x0f, 0x1f, 0x1c, 0x0c, 0x0f, 0x08, 0x48, 0x98,
+			0x37, 0x0a, 0x13, 0x04, 0x11, 0x0d, 0x00,
+		},
+		Negative: [15]uint8{
+			0x0f, 0x32, 0x2e, 0x0b, 0x0d, 0x05, 0x47, 0x75,
+			0x37, 0x06, 0x10, 0x03, 0x24, 0x20, 0x00,
+		},
+	}
+)
+
+// gammaField is a reference level coefficient within a gamma table entry.
+type gammaField struct {
+	level uint8 // gray level 0..63
+	shift uint8 // bit position in the entry
+	bits  uint8 // field width
+}
+
+// gammaFields lists the fields of the positive table, the negative table
+// holds the same fields in reverse order. Entry 8 packs two levels.
+var gammaFields = [15][]gammaField{
+	{{63, 0, 4}},
+	{{62, 0, 6}},
+	{{61, 0, 6}},
+	{{59, 0, 5}},
+	{{57, 0, 5}},
+	{{50, 0, 4}},
+	{{43, 0, 7}},
+	{{36, 4, 4}, {27, 0, 4}},
+	{{20, 0, 7}},
+	{{13, 0, 4}},
+	{{6, 0, 5}},
+	{{4, 0, 5}},
+	{{2, 0, 6}},
+	{{1, 0, 6}},
+	{{0, 0, 4}},
+}
+
+// GammaCurve derives gamma tables for a target gamma value, e.g. 2.2, from
+// GammaILI9488. Each reference level coefficient is moved by the difference
+// between the target curve and a 2.2 curve at that level, scaled to the width
+// of the field. The result is an approximation meant as a starting point for
+// tuning a batch of panels by eye.
+func GammaCurve(gamma float64) Gamma {
+	g := GammaILI9488
+	for i, fields := range gammaFields {
+		for _, f := range fields {
+			g.Positive[i] = adjustGamma(g.Positive[i], f, gamma)
+			g.Negative[14-i] = adjustGamma(g.Negative[14-i], f, gamma)
+		}
+	}
+	return g
+}
+
+// adjustGamma moves one field of a table entry for a target gamma value.
+func adjustGamma(v uint8, f gammaField, gamma float64) uint8 {
+	const ref = 2.2
+	lvl := float64(f.level) / 63
+	delta := math.Pow(lvl, gamma) - math.Pow(lvl, ref)
+
+	max := int(1)<<f.bits - 1
+	mask := uint8(max) << f.shift
+	c := int(v&mask>>f.shift) + int(math.Round(delta*float64(max)))
+	if c < 0 {
+		c = 0
+	} else if c > max {
+		c = max
+	}
+	return v&^mask | uint8(c)<<f.shift
+}
+
+// WithGamma programs the gamma tables after the init sequence.
+func WithGamma(g Gamma) Option {
+	return func(disp *Ili948x) {
+		disp.gamma = &g
+	}
+}
+
+// SetGamma programs the positive and negative gamma correction tables.
+func (disp *Ili948x) SetGamma(g Gamma) error {
+	if err := disp.writeCmd(CMD_PGAMCTRL, g.Positive[:]...); err != nil {
+		return err
+	}
+	return disp.writeCmd(CMD_NGAMCTRL, g.Negative[:]...)
+}
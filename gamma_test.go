@@ -0,0 +1,83 @@
+package ili948x_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/trace"
+)
+
+// gammaBits are the widths of the entries of the positive gamma table, the
+// negative table has them in reverse order. Entry 7 packs two 4 bit levels.
+var gammaBits = [15]uint{4, 6, 6, 5, 5, 4, 7, 8, 7, 4, 5, 5, 6, 6, 4}
+
+func TestGammaCurve(t *testing.T) {
+	if g := ili948x.GammaCurve(2.2); g != ili948x.GammaILI9488 {
+		t.Errorf("GammaCurve(2.2) = %x, want GammaILI9488 %x", g, ili948x.GammaILI9488)
+	}
+
+	for _, gamma := range []float64{0.1, 1, 1.8, 2.5, 3, 10, 1000} {
+		g := ili948x.GammaCurve(gamma)
+		if g == ili948x.GammaILI9488 {
+			t.Errorf("GammaCurve(%g) is the 2.2 curve", gamma)
+		}
+		for i, bits := range gammaBits {
+			if g.Positive[i]>>bits != 0 || g.Negative[14-i]>>bits != 0 {
+				t.Errorf("GammaCurve(%g): entry %d: %#02x, %#02x wider than %d bits",
+					gamma, i, g.Positive[i], g.Negative[14-i], bits)
+			}
+		}
+		// the end levels, V0 and V63, are the same on every curve
+		ref := ili948x.GammaILI9488
+		for _, i := range []int{0, 14} {
+			if g.Positive[i] != ref.Positive[i] || g.Negative[i] != ref.Negative[i] {
+				t.Errorf("GammaCurve(%g): end entry %d changed", gamma, i)
+			}
+		}
+	}
+}
+
+func TestWithGamma(t *testing.T) {
+	g := ili948x.GammaCurve(1.8)
+	rec := trace.NewRecorder()
+	disp, err := ili948x.NewIli9488(rec, nil, rec.DC(), nil, nil, 0, 0, ili948x.WithGamma(g))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the tables follow the whole init sequence, including its own gamma
+	ops := rec.Ops()
+	if !runsSequence(ops, ili948x.ProfileILI9488) {
+		t.Fatalf("init did not run the profile:\n%s", rec)
+	}
+	last := -1
+	for i, op := range ops {
+		if op.Cmd == ili948x.CMD_DISON {
+			last = i
+		}
+	}
+	want := []trace.Op{
+		{Cmd: ili948x.CMD_PGAMCTRL, Params: g.Positive[:]},
+		{Cmd: ili948x.CMD_NGAMCTRL, Params: g.Negative[:]},
+	}
+	var got []trace.Op
+	for _, op := range ops[last+1:] {
+		if op.Cmd == ili948x.CMD_PGAMCTRL || op.Cmd == ili948x.CMD_NGAMCTRL {
+			got = append(got, op)
+		}
+	}
+	if len(got) != 2 || got[0].Cmd != want[0].Cmd || !bytes.Equal(got[0].Params, want[0].Params) ||
+		got[1].Cmd != want[1].Cmd || !bytes.Equal(got[1].Params, want[1].Params) {
+		t.Errorf("after the init sequence got %v, want %v", got, want)
+	}
+
+	rec.Reset()
+	if err := disp.SetGamma(ili948x.GammaMakerfabsESP32C3); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_PGAMCTRL, Params: ili948x.GammaMakerfabsESP32C3.Positive[:]},
+		{Cmd: ili948x.CMD_NGAMCTRL, Params: ili948x.GammaMakerfabsESP32C3.Negative[:]},
+	})
+}
@@ -1,8 +1,10 @@
-module main
+module github.com/inindev/ili948x
 
 go 1.19
 
 require (
+	golang.org/x/image v0.18.0
+	golang.org/x/text v0.16.0
 	tinygo.org/x/drivers v0.23.0
 	tinygo.org/x/tinyfs v0.2.0
 )
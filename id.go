@@ -0,0 +1,108 @@
+package ili948x
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Controller is a display controller model.
+type Controller uint8
+
+const (
+	UnknownController Controller = iota
+	ILI9486
+	ILI9488
+)
+
+// String returns the controller model name.
+func (c Controller) String() string {
+	switch c {
+	case ILI9486:
+		return "ILI9486"
+	case ILI9488:
+		return "ILI9488"
+	}
+	return "unknown"
+}
+
+// ID is the identification read from the display controller.
+type ID struct {
+	Manufacturer uint8      // CMD_RDDIDIF ID1: lcd module manufacturer
+	Version      uint8      // CMD_RDDIDIF ID2: lcd module / driver version
+	Module       uint8      // CMD_RDDIDIF ID3: lcd module / driver
+	IC           uint16     // CMD_RDID4 ic model, e.g. 0x9488
+	Controller   Controller // controller model derived from IC
+}
+
+// New returns a reset and initialized display with its backlight on, reading
+// the controller ID to select the matching init sequence and pixel format:
+// 18 bits / pixel for the ILI9488, 16 bits / pixel for the ILI9486.
+// An error wrapping ErrUnknownController is returned for unknown controllers.
+// The pins must already be configured as outputs; cs, bl and rst may be nil
+// when not connected.
+func New(trans ReadTransport, cs, dc, bl, rst Pin, width, height uint16, opts ...Option) (*Ili948x, error) {
+	disp := newIli948x(trans, cs, dc, bl, rst, width, height, opts)
+
+	// reset the display
+	if err := disp.Reset(); err != nil {
+		return nil, err
+	}
+
+	id, err := disp.ReadID()
+	if err != nil {
+		return nil, err
+	}
+	if id.Controller == UnknownController {
+		return nil, &Error{
+			Op:   "New",
+			Kind: ErrUnknownController,
+			Err:  errors.New("RDID4 ic model 0x" + strconv.FormatUint(uint64(id.IC), 16)),
+		}
+	}
+	disp.setID(id)
+
+	if err := disp.start(); err != nil {
+		return nil, err
+	}
+	return disp, nil
+}
+
+// ID returns the controller identification read at startup. Displays created
+// with a model specific constructor report the model without reading it.
+func (disp *Ili948x) ID() ID {
+	return disp.id
+}
+
+// setID sets the controller identification and its default pixel format.
+func (disp *Ili948x) setID(id ID) {
+	disp.id = id
+	disp.pixfmt = PixelFormat18
+	if id.Controller == ILI9486 {
+		disp.pixfmt = PixelFormat16
+	}
+}
+
+// ReadID reads the controller identification with CMD_RDDIDIF and CMD_RDID4.
+// The transport must implement ReadTransport.
+func (disp *Ili948x) ReadID() (ID, error) {
+	var id ID
+	buf := make([]uint8, 3)
+
+	if err := disp.readCmd(CMD_RDDIDIF, buf); err != nil {
+		return id, err
+	}
+	id.Manufacturer, id.Version, id.Module = buf[0], buf[1], buf[2]
+
+	if err := disp.readCmd(CMD_RDID4, buf); err != nil {
+		return id, err
+	}
+	id.IC = uint16(buf[1])<<8 | uint16(buf[2]) // buf[0]: ic version
+
+	switch id.IC {
+	case 0x9486:
+		id.Controller = ILI9486
+	case 0x9488:
+		id.Controller = ILI9488
+	}
+	return id, nil
+}
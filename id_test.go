@@ -0,0 +1,117 @@
+package ili948x_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/sim"
+	"github.com/inindev/ili948x/trace"
+)
+
+// runsSequence reports whether ops contain the commands of seq in a row.
+func runsSequence(ops []trace.Op, seq ili948x.InitSequence) bool {
+	for i := 0; i+len(seq) <= len(ops); i++ {
+		match := true
+		for j, c := range seq {
+			if ops[i+j].Cmd != c.Cmd || !bytes.Equal(ops[i+j].Params, c.Params) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNewDetect(t *testing.T) {
+	for _, tt := range []struct {
+		ic      uint16
+		want    ili948x.Controller
+		pixfmt  ili948x.PixelFormat
+		profile ili948x.InitSequence
+	}{
+		{0x9486, ili948x.ILI9486, ili948x.PixelFormat16, ili948x.ProfileWaveshareILI9486},
+		{0x9488, ili948x.ILI9488, ili948x.PixelFormat18, ili948x.ProfileILI9488},
+	} {
+		// RDDIDIF then RDID4, each after a dummy byte
+		rec := trace.NewRecorder()
+		rec.Respond(0x00, 0x54, 0x80, 0x66, 0x00, 0x01, uint8(tt.ic>>8), uint8(tt.ic))
+		disp, err := ili948x.New(rec, nil, rec.DC(), nil, nil, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := ili948x.ID{Manufacturer: 0x54, Version: 0x80, Module: 0x66, IC: tt.ic, Controller: tt.want}
+		if id := disp.ID(); id != want {
+			t.Errorf("%#04x: got ID %+v, want %+v", tt.ic, id, want)
+		}
+		if f := disp.GetPixelFormat(); f != tt.pixfmt {
+			t.Errorf("%#04x: got pixel format %#02x, want %#02x", tt.ic, f, tt.pixfmt)
+		}
+		ops := rec.Ops()
+		if f := pixfmt(ops); f != uint8(tt.pixfmt) {
+			t.Errorf("%#04x: init set pixel format %#02x", tt.ic, f)
+		}
+		if !runsSequence(ops, tt.profile) {
+			t.Errorf("%#04x: init did not run the %v profile:\n%s", tt.ic, tt.want, rec)
+		}
+	}
+}
+
+func TestNewSimulated(t *testing.T) {
+	for _, tt := range []struct {
+		ic     uint16
+		want   ili948x.Controller
+		pixfmt ili948x.PixelFormat
+	}{
+		{0x9486, ili948x.ILI9486, ili948x.PixelFormat16},
+		{0x9488, ili948x.ILI9488, ili948x.PixelFormat18},
+	} {
+		disp, d := newSimulatedIC(t, tt.ic)
+		want := ili948x.ID{Manufacturer: d.ID[0], Version: d.ID[1], Module: d.ID[2], IC: tt.ic, Controller: tt.want}
+		if id := disp.ID(); id != want {
+			t.Errorf("%#04x: got ID %+v, want %+v", tt.ic, id, want)
+		}
+		if id, err := disp.ReadID(); err != nil || id != want {
+			t.Errorf("%#04x: ReadID got %+v, %v", tt.ic, id, err)
+		}
+		if f := disp.GetPixelFormat(); f != tt.pixfmt {
+			t.Errorf("%#04x: got pixel format %#02x, want %#02x", tt.ic, f, tt.pixfmt)
+		}
+		// the controller takes the pixels in the chosen format
+		if err := disp.FillRectangle(3, 4, 1, 1, 0x40fc84); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := d.Pixel(3, 4), uint32(0x21<<12|0x3f<<6|0x10); got != want {
+			t.Errorf("%#04x: got pixel %#05x, want %#05x", tt.ic, got, want)
+		}
+	}
+}
+
+func TestNewUnknownController(t *testing.T) {
+	d := sim.New()
+	d.ID4 = [3]uint8{0x00, 0x93, 0x41}
+	disp, err := ili948x.New(d, nil, d.DC(), nil, nil, 0, 0)
+	if !errors.Is(err, ili948x.ErrUnknownController) || disp != nil {
+		t.Fatalf("got %v, %v, want ErrUnknownController", disp, err)
+	}
+	if !strings.Contains(err.Error(), "0x9341") {
+		t.Errorf("error %q does not name the ic", err)
+	}
+
+	// the init sequence is not sent to an unknown controller
+	rec := trace.NewRecorder()
+	rec.Respond(0x00, 0x54, 0x80, 0x66, 0x00, 0x00, 0x93, 0x41)
+	if _, err := ili948x.New(rec, nil, rec.DC(), nil, nil, 0, 0); !errors.Is(err, ili948x.ErrUnknownController) {
+		t.Fatalf("got %v, want ErrUnknownController", err)
+	}
+	for _, cmd := range rec.Commands() {
+		if cmd == ili948x.CMD_PIXFMT || cmd == ili948x.CMD_SLPOUT {
+			t.Errorf("sent %#02x", cmd)
+		}
+	}
+}
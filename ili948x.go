@@ -1,9 +1,8 @@
-package main
+// Package ili948x implements a driver for the ILI9488 and ILI9486 SPI TFT display controllers.
+package ili948x
 
 import (
-	"errors"
 	"io"
-	"machine"
 	"time"
 )
 
@@ -22,21 +21,79 @@ const (
 )
 
 type Ili948x struct {
-	trans  iTransport
-	cs     machine.Pin // spi chip select
-	dc     machine.Pin // tft data / command
-	bl     machine.Pin // tft backlight
-	rst    machine.Pin // tft reset
-	width  uint16      // tft pixel width
-	height uint16      // tft pixel height
-	rot    Rotation    // tft orientation
-	mirror bool        // mirror tft output
-	bgr    bool        // tft blue-green-red mode
-	x0, x1 uint16      // current address window for
-	y0, y1 uint16      //  CMD_PASET and CMD_CASET
-}
-
-func NewIli9488(trans iTransport, cs, dc, bl, rst machine.Pin, width, height uint16) *Ili948x {
+	trans         Transport
+	cs            Pin           // spi chip select
+	dc            Pin           // tft data / command
+	bl            Pin           // tft backlight
+	rst           Pin           // tft reset
+	width         uint16        // tft pixel width
+	height        uint16        // tft pixel height
+	rot           Rotation      // tft orientation
+	mirror        bool          // mirror tft output
+	bgr           bool          // tft blue-green-red mode
+	x0, x1        uint16        // current address window for
+	y0, y1        uint16        //  CMD_PASET and CMD_CASET
+	id            ID            // controller identification
+	pixfmt        PixelFormat   // interface pixel format
+	buf16         []uint16      // 16 bit pixel conversion buffer
+	buf8          []uint8       // CMD_RAMRD row buffer
+	initSeq       InitSequence  // init sequence, nil for the controller default
+	gamma         *Gamma        // gamma tables programmed after init, if set
+	power         PowerState    // sleep, idle and display on state
+	brightness    uint8         // backlight brightness
+	bctrl         bctrlSupport  // backlight driven by the brightness control block
+	bctrlOn       bool          // brightness control block turned on since reset
+	te            InputPin      // tearing effect line
+	teLine        uint16        // CMD_TESLWR scan line
+	teOn          bool          // TE output enabled
+	teTimeout     time.Duration // longest wait for a TE pulse
+	scrollReverse bool          // MADCTRL_ML flipped, see SetScrollReverse
+	tfa, vsa, bfa uint16        // vertical scrolling definition
+	vsp           uint16        // vertical scrolling start address
+	textRow       []uint32      // text pixel row buffer
+	textGlyphs    []*Glyph      // glyphs of a text line
+	textPens      []int         // pen positions of a text line
+	polySpans     []span        // spans of a polygon row
+	polyXs        []int64       // edge crossings of a polygon row, 16.16 fixed point
+}
+
+// Option configures a display in its constructor.
+type Option func(*Ili948x)
+
+// NewIli9488 returns a reset and initialized ILI9488 display with its backlight on.
+// The init sequence defaults to ProfileILI9488 and may be replaced with
+// WithInitSequence. The pins must already be configured as outputs; cs, bl and
+// rst may be nil when not connected.
+func NewIli9488(trans Transport, cs, dc, bl, rst Pin, width, height uint16, opts ...Option) (*Ili948x, error) {
+	return newModel(ID{IC: 0x9488, Controller: ILI9488}, trans, cs, dc, bl, rst, width, height, opts)
+}
+
+// NewIli9486 returns a reset and initialized ILI9486 display with its backlight on,
+// using the 16 bit rgb565 pixel format and ProfileWaveshareILI9486 by default.
+// The pins must already be configured as outputs; cs, bl and rst may be nil when
+// not connected.
+func NewIli9486(trans Transport, cs, dc, bl, rst Pin, width, height uint16, opts ...Option) (*Ili948x, error) {
+	return newModel(ID{IC: 0x9486, Controller: ILI9486}, trans, cs, dc, bl, rst, width, height, opts)
+}
+
+// newModel returns a reset and initialized display of a known controller model.
+func newModel(id ID, trans Transport, cs, dc, bl, rst Pin, width, height uint16, opts []Option) (*Ili948x, error) {
+	disp := newIli948x(trans, cs, dc, bl, rst, width, height, opts)
+	disp.setID(id)
+
+	// reset the display
+	if err := disp.Reset(); err != nil {
+		return nil, err
+	}
+
+	if err := disp.start(); err != nil {
+		return nil, err
+	}
+	return disp, nil
+}
+
+// newIli948x returns a display with its control pins in their idle state.
+func newIli948x(trans Transport, cs, dc, bl, rst Pin, width, height uint16, opts []Option) *Ili948x {
 	if width == 0 {
 		width = TFT_DEFAULT_WIDTH
 	}
@@ -45,157 +102,363 @@ func NewIli9488(trans iTransport, cs, dc, bl, rst machine.Pin, width, height uin
 	}
 
 	disp := &Ili948x{
-		trans:  trans,
-		cs:     cs,
-		dc:     dc,
-		bl:     bl,
-		rst:    rst,
-		width:  width,
-		height: height,
-		rot:    Rot_0,
-		mirror: false,
-		bgr:    false,
-		x0:     0,
-		x1:     0,
-		y0:     0,
-		y1:     0,
+		trans:     trans,
+		cs:        cs,
+		dc:        dc,
+		bl:        bl,
+		rst:       rst,
+		width:     width,
+		height:    height,
+		rot:       Rot_0,
+		teTimeout: defaultTETimeout,
+		mirror:    false,
+		bgr:       false,
+		x0:        0,
+		x1:        0,
+		y0:        0,
+		y1:        0,
+	}
+	for _, opt := range opts {
+		opt(disp)
 	}
 
 	// chip select pin
-	if cs != machine.NoPin { // cs may be implemented by hardware spi
-		cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	if cs != nil { // cs may be implemented by hardware spi
 		cs.High()
 	}
 
 	// data/command pin
-	dc.Configure(machine.PinConfig{Mode: machine.PinOutput})
 	dc.High()
 
 	// backlight pin
-	if bl != machine.NoPin {
-		bl.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	if bl != nil {
 		bl.Low() // display off
 	}
 
 	// reset pin
-	if rst != machine.NoPin {
-		disp.rst.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	if rst != nil {
 		disp.rst.High()
 	}
 
-	// reset the display
-	disp.Reset()
+	return disp
+}
 
+// start initializes a freshly reset display and turns its backlight on.
+func (disp *Ili948x) start() error {
 	// init display settings
-	disp.init()
+	if err := disp.init(); err != nil {
+		return err
+	}
 
 	// display backlight on
-	disp.SetBacklight(true)
-
-	return disp
+	return disp.SetBacklight(true)
 }
 
 // Size returns the current size of the display.
-func (disp *Ili948x) Size() (uint16, uint16) {
+func (disp *Ili948x) Size() (int16, int16) {
 	if disp.rot == Rot_0 || disp.rot == Rot_180 {
-		return disp.width, disp.height
+		return int16(disp.width), int16(disp.height)
 	}
-	return disp.height, disp.width
+	return int16(disp.height), int16(disp.width)
 }
 
 // DrawPixel draws a single pixel with the specified color.
-func (disp *Ili948x) DrawPixel(x, y uint16, color uint32) error {
+func (disp *Ili948x) DrawPixel(x, y int16, color uint32) error {
 	return disp.FillRectangle(x, y, 1, 1, color)
 }
 
 // DrawHLine draws a horizontal line with the specified color.
-func (disp *Ili948x) DrawHLine(x0, x1, y uint16, color uint32) error {
+func (disp *Ili948x) DrawHLine(x0, x1, y int16, color uint32) error {
 	if x0 > x1 {
 		x0, x1 = x1, x0
 	}
-	return disp.FillRectangle(x0, y, x1-x0+1, 1, color)
+	cx, cy, cw, ch, ok := disp.clip(int32(x0), int32(y), int32(x1)-int32(x0)+1, 1)
+	if !ok {
+		return nil
+	}
+	return disp.fillWindow(cx, cy, cw, ch, color)
 }
 
 // DrawVLine draws a vertical line with the specified color.
-func (disp *Ili948x) DrawVLine(x, y0, y1 uint16, color uint32) error {
+func (disp *Ili948x) DrawVLine(x, y0, y1 int16, color uint32) error {
 	if y0 > y1 {
 		y0, y1 = y1, y0
 	}
-	return disp.FillRectangle(x, y0, 1, y1-y0+1, color)
+	cx, cy, cw, ch, ok := disp.clip(int32(x), int32(y0), 1, int32(y1)-int32(y0)+1)
+	if !ok {
+		return nil
+	}
+	return disp.fillWindow(cx, cy, cw, ch, color)
 }
 
 // FillScreen fills the screen with the specified color.
-func (disp *Ili948x) FillScreen(color uint32) {
-	if disp.rot == Rot_0 || disp.rot == Rot_180 {
-		disp.FillRectangle(0, 0, disp.width, disp.height, color)
-	} else {
-		disp.FillRectangle(0, 0, disp.height, disp.width, color)
-	}
+func (disp *Ili948x) FillScreen(color uint32) error {
+	w, h := disp.Size()
+	return disp.FillRectangle(0, 0, w, h, color)
 }
 
 // FillRectangle fills a rectangle at given coordinates and dimensions with the specified color.
-func (disp *Ili948x) FillRectangle(x, y, width, height uint16, color uint32) error {
-	w, h := disp.Size()
-	if x >= w || (x+width) > w || y >= h || (y+height) > h {
-		return errors.New("rectangle coordinates outside display area")
+// The rectangle is clipped to the display, only its visible part is drawn.
+func (disp *Ili948x) FillRectangle(x, y, width, height int16, color uint32) error {
+	return disp.fillRect(int32(x), int32(y), int32(width), int32(height), color)
+}
+
+// DisplayBitmap renders the streamed image at given coordinates and dimensions.
+// Pixels are 24 bpp (blue, green, red bytes) or 16 bpp (little-endian rgb565 words)
+// and are converted to the display pixel format as needed.
+// The image is clipped to the display: source pixels outside of it are read and skipped.
+// A short stream ends the image after its last whole pixel.
+func (disp *Ili948x) DisplayBitmap(x, y, width, height int16, bpp uint8, r io.Reader) error {
+	if bpp != 16 && bpp != 24 {
+		return &Error{Op: "DisplayBitmap", Kind: ErrNotSupported}
+	}
+	cx, cy, cw, ch, ok := disp.clip(int32(x), int32(y), int32(width), int32(height))
+	if !ok {
+		return nil
+	}
+	if err := disp.startRAMWR(cx, cy, cw, ch); err != nil {
+		return err
 	}
-	disp.setWindow(x, y, width, height)
 
-	disp.writeCmd(CMD_RAMWR)
-	disp.startWrite()
-	disp.trans.write24n(color, int(width)*int(height))
-	disp.endWrite()
+	bytesPP := int(bpp / 8)
+	skipRows := int(cy) - int(y)             // source rows above the display
+	left := (int(cx) - int(x)) * bytesPP     // source bytes left of the display
+	right := left + int(cw)*bytesPP          // end of the visible source bytes
+	buf := make([]uint8, int(width)*bytesPP) // one source row
+
+	// 24 bpp is streamed as-is in the 18 bit pixel format
+	raw := bpp == 24 && disp.pixfmt == PixelFormat18
+	var colors []uint32
+	if !raw {
+		colors = make([]uint32, cw)
+	}
+
+	for row := 0; row < skipRows+int(ch); row++ {
+		// whole rows are read so pixels split across reads stay in order
+		n, err := io.ReadFull(r, buf)
+		if row >= skipRows && n >= left+bytesPP {
+			end := right
+			if n < end {
+				end = left + (n-left)/bytesPP*bytesPP // drop a partial pixel
+			}
+			var werr error
+			if raw {
+				disp.startWrite()
+				werr = busError("write pixels", disp.trans.Write8sl(buf[left:end]))
+				disp.endWrite()
+			} else {
+				px := colors[:(end-left)/bytesPP]
+				for i := range px {
+					p := buf[left+i*bytesPP:]
+					if bpp == 24 {
+						px[i] = uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16
+					} else {
+						px[i] = RGB565ToColor(le16(p))
+					}
+				}
+				werr = disp.writePixels(px)
+			}
+			if werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break // short image
+		}
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-// DisplayBitmap renders the streamed image at given coordinates and dimensions.
-func (disp *Ili948x) DisplayBitmap(x, y, width, height uint16, bpp uint8, r io.Reader) error {
+// ReadRectangle reads back the frame memory of a rectangle with CMD_RAMRD into buf,
+// one color per pixel in row order, with the 18 bit precision of the frame memory.
+// The rectangle must be on-screen and the transport must implement ReadTransport.
+func (disp *Ili948x) ReadRectangle(x, y, width, height int16, buf []uint32) error {
+	const op = "ReadRectangle"
 	w, h := disp.Size()
-	if x >= w || (x+width) > w || y >= h || (y+height) > h {
-		return errors.New("rectangle coordinates outside display area")
+	if x < 0 || y < 0 || width <= 0 || height <= 0 || int32(x)+int32(width) > int32(w) || int32(y)+int32(height) > int32(h) {
+		return &Error{Op: op, Kind: ErrOutOfBounds}
+	}
+	if len(buf) < int(width)*int(height) {
+		return io.ErrShortBuffer
+	}
+	if _, ok := disp.trans.(ReadTransport); !ok {
+		return &Error{Op: op, Kind: ErrNotSupported}
+	}
+	if err := disp.setWindow(uint16(x), uint16(y), uint16(width), uint16(height)); err != nil {
+		return err
 	}
-	disp.setWindow(x, y, width, height)
 
-	disp.writeCmd(CMD_RAMWR)
-	buf := make([]uint8, width*uint16(bpp/3))
-	for {
-		n, err := r.Read(buf)
-		if n == 0 || err == io.EOF {
-			break
+	if cap(disp.buf8) < int(width)*3 {
+		disp.buf8 = make([]uint8, int(width)*3)
+	}
+	row := disp.buf8[:int(width)*3]
+	return disp.readCmdFunc(CMD_RAMRD, func(rt ReadTransport) error {
+		for i := 0; i < int(height); i++ {
+			if err := rt.Read8sl(row); err != nil {
+				return err
+			}
+			line := buf[i*int(width):]
+			for j := range line[:width] {
+				// bytes arrive in the order they were written
+				line[j] = uint32(row[j*3]) | uint32(row[j*3+1])<<8 | uint32(row[j*3+2])<<16
+			}
 		}
+		return nil
+	})
+}
 
-		disp.startWrite()
-		disp.trans.write8sl(buf[:n])
-		disp.endWrite()
+// clip intersects a rectangle with the display, returning false if nothing is visible.
+func (disp *Ili948x) clip(x, y, width, height int32) (uint16, uint16, uint16, uint16, bool) {
+	w, h := disp.Size()
+	x0, y0 := max32(x, 0), max32(y, 0)
+	x1, y1 := min32(x+width, int32(w)), min32(y+height, int32(h))
+	if x0 >= x1 || y0 >= y1 {
+		return 0, 0, 0, 0, false
 	}
+	return uint16(x0), uint16(y0), uint16(x1 - x0), uint16(y1 - y0), true
+}
 
-	return nil
+// fillWindow fills an on-screen rectangle with a single color.
+func (disp *Ili948x) fillWindow(x, y, width, height uint16, color uint32) error {
+	if err := disp.startRAMWR(x, y, width, height); err != nil {
+		return err
+	}
+	return disp.fillPixels(color, int(width)*int(height))
 }
 
-// SetScrollArea sets an area to scroll with fixed top/bottom or left/right parts of the display
-// Rotation affects scroll direction
-func (disp *Ili948x) SetScrollArea(topFixedArea, bottomFixedArea uint16) {
+// writeWindow writes width * height colors to an on-screen rectangle.
+func (disp *Ili948x) writeWindow(x, y, width, height uint16, colors []uint32) error {
+	if err := disp.startRAMWR(x, y, width, height); err != nil {
+		return err
+	}
+	return disp.writePixels(colors)
+}
+
+// startRAMWR sets the address window and starts a CMD_RAMWR memory write.
+func (disp *Ili948x) startRAMWR(x, y, width, height uint16) error {
+	if disp.power.Asleep {
+		return &Error{Op: "draw", Kind: ErrAsleep}
+	}
+	if err := disp.setWindow(x, y, width, height); err != nil {
+		return err
+	}
+	return disp.writeCmd(CMD_RAMWR)
+}
+
+// SetScrollArea defines the scroll area between a fixed area at the start and
+// one at the end of the display. The controller scrolls along its gate lines,
+// so like SetPartialArea the areas are rows, scrolling up and down, in Rot_0
+// and Rot_180, and columns, scrolling left and right, in Rot_90 and Rot_270.
+// Lines count from the top or left of the display in every rotation, also
+// with the scroll direction reversed. Call StopScroll before rotating a
+// scrolled display.
+func (disp *Ili948x) SetScrollArea(topFixedArea, bottomFixedArea uint16) error {
+	if uint32(topFixedArea)+uint32(bottomFixedArea) > uint32(disp.height) {
+		return &Error{Op: "SetScrollArea", Kind: ErrOutOfBounds}
+	}
 	vertScrollArea := disp.height - topFixedArea - bottomFixedArea
-	disp.writeCmd(CMD_VSCRDEF,
-		uint8(topFixedArea>>8),
-		uint8(topFixedArea),
+	// the MADCTRL_ML scan direction of every rotation keeps logical lines
+	// and scroll definition lines in the same order, with the scroll
+	// direction reversed the controller counts them from the other end
+	tfa, bfa := topFixedArea, bottomFixedArea
+	if disp.scrollReverse {
+		tfa, bfa = bfa, tfa
+	}
+	if err := disp.writeCmd(CMD_VSCRDEF,
+		uint8(tfa>>8),
+		uint8(tfa),
 		uint8(vertScrollArea>>8),
 		uint8(vertScrollArea),
-		uint8(bottomFixedArea>>8),
-		uint8(bottomFixedArea))
+		uint8(bfa>>8),
+		uint8(bfa)); err != nil {
+		return err
+	}
+	disp.tfa, disp.vsa, disp.bfa = topFixedArea, vertScrollArea, bottomFixedArea
+	return nil
+}
+
+// GetScrollArea returns the fixed and scroll areas in lines, adding up to the
+// rows in Rot_0 and Rot_180 and the columns in Rot_90 and Rot_270.
+func (disp *Ili948x) GetScrollArea() (topFixedArea, scrollArea, bottomFixedArea uint16) {
+	return disp.tfa, disp.vsa, disp.bfa
+}
+
+// SetScroll sets the line shown at the start of the scroll area, from
+// topFixedArea to topFixedArea + scrollArea - 1. Setting topFixedArea shows
+// the scroll area unscrolled. Increasing lines move the content up, or left in
+// Rot_90 and Rot_270, and the other way with SetScrollReverse.
+func (disp *Ili948x) SetScroll(line uint16) error {
+	if line < disp.tfa || line-disp.tfa >= disp.vsa {
+		return &Error{Op: "SetScroll", Kind: ErrOutOfBounds}
+	}
+	vsp := line
+	if disp.scrollReverse {
+		vsp = line - disp.tfa + disp.bfa
+	}
+	if err := disp.writeCmd(CMD_VSCRSADD,
+		uint8(vsp>>8),
+		uint8(vsp)); err != nil {
+		return err
+	}
+	disp.vsp = line
+	return nil
+}
+
+// scrollForward shows the scroll area moved up, or left, by offset lines
+// whatever the scroll direction.
+func (disp *Ili948x) scrollForward(offset uint16) error {
+	if disp.scrollReverse && offset != 0 {
+		offset = disp.vsa - offset
+	}
+	return disp.SetScroll(disp.tfa + offset)
+}
+
+// GetScrollReverse returns true if the scroll direction is reversed.
+func (disp *Ili948x) GetScrollReverse() bool {
+	return disp.scrollReverse
 }
 
-// SetScroll sets the vertical scroll address of the display.
-func (disp *Ili948x) SetScroll(line uint16) {
-	disp.writeCmd(CMD_VSCRSADD,
-		uint8(line>>8),
-		uint8(line))
+// SetScrollReverse reverses the scroll direction by flipping the MADCTRL_ML
+// refresh order, so increasing SetScroll lines move the content down, or
+// right. The panel then refreshes from the other end. The scroll area and
+// line are kept.
+func (disp *Ili948x) SetScrollReverse(reverse bool) error {
+	disp.scrollReverse = reverse
+	if err := disp.updateMadctl(); err != nil {
+		return err
+	}
+	// the controller's lines now count from the other end
+	if err := disp.SetScrollArea(disp.tfa, disp.bfa); err != nil {
+		return err
+	}
+	return disp.SetScroll(disp.vsp)
+}
+
+// gateLine returns the controller line of a display line along the gate
+// lines, counting from the bottom or right with the scroll direction reversed.
+func (disp *Ili948x) gateLine(line uint16) uint16 {
+	if disp.scrollReverse {
+		return disp.height - 1 - line
+	}
+	return line
+}
+
+// GetScroll returns the line shown at the start of the scroll area.
+func (disp *Ili948x) GetScroll() uint16 {
+	return disp.vsp
 }
 
 // StopScroll returns the display to its normal state
-func (disp *Ili948x) StopScroll() {
-	disp.writeCmd(CMD_NORON)
+func (disp *Ili948x) StopScroll() error {
+	if err := disp.writeCmd(CMD_NORON); err != nil {
+		return err
+	}
+	disp.vsp = disp.tfa
+	return nil
 }
 
 // GetRotation returns the current rotation of the display.
@@ -204,9 +467,9 @@ func (disp *Ili948x) GetRotation() Rotation {
 }
 
 // SetRotation sets the clock-wise rotation of the display.
-func (disp *Ili948x) SetRotation(rot Rotation) {
+func (disp *Ili948x) SetRotation(rot Rotation) error {
 	disp.rot = rot
-	disp.updateMadctl()
+	return disp.updateMadctl()
 }
 
 // GetMirror returns true if the display set to display a mirrored image.
@@ -215,9 +478,9 @@ func (disp *Ili948x) GetMirror() bool {
 }
 
 // SetMirror switches the display between mirrored image and non-mirrored image mode.
-func (disp *Ili948x) SetMirror(mirror bool) {
+func (disp *Ili948x) SetMirror(mirror bool) error {
 	disp.mirror = mirror
-	disp.updateMadctl()
+	return disp.updateMadctl()
 }
 
 // GetBGR returns true if the display is in blue-green-red (BGR) mode.
@@ -226,60 +489,88 @@ func (disp *Ili948x) GetBGR() bool {
 }
 
 // SetBGR switches the display between blue-green-red (BGR) and red-green-blue (RGB) mode.
-func (disp *Ili948x) SetBGR(bgr bool) {
+func (disp *Ili948x) SetBGR(bgr bool) error {
 	disp.bgr = bgr
-	disp.updateMadctl()
+	return disp.updateMadctl()
 }
 
 // SetBacklight turns the TFT backlight on / off.
-func (disp *Ili948x) SetBacklight(b bool) {
-	if disp.bl != machine.NoPin {
+func (disp *Ili948x) SetBacklight(b bool) error {
+	if disp.bl != nil {
 		disp.bl.Set(b)
+		disp.brightness = 0
+		if b {
+			disp.brightness = 0xff
+		}
 	}
+	return nil
 }
 
 // Reset performs a hardware reset if rst pin present, otherwise performs a CMD_SWRESET software reset of the TFT display.
-func (disp *Ili948x) Reset() {
+// The controller is left in sleep mode with the display off.
+func (disp *Ili948x) Reset() error {
 	// prefer a hardware reset if there is one
-	if disp.rst != machine.NoPin {
+	if disp.rst != nil {
 		disp.rst.Low()
 		time.Sleep(time.Millisecond * 64) // datasheet says 10ms
 		disp.rst.High()
 	} else {
 		// if no hardware reset, send software reset
-		disp.writeCmd(CMD_SWRESET)
+		if err := disp.writeCmd(CMD_SWRESET); err != nil {
+			return err
+		}
 	}
 	time.Sleep(time.Millisecond * 140) // datasheet says 120ms
+
+	// the controller resets its address window to the whole frame memory,
+	// an impossible cached window makes the next setWindow send it in full
+	disp.x0, disp.x1, disp.y0, disp.y1 = 0xffff, 0xffff, 0xffff, 0xffff
+	disp.tfa, disp.vsa, disp.bfa, disp.vsp = 0, disp.height, 0, 0
+	disp.power = PowerState{Asleep: true}
+	disp.bctrlOn = false
+	disp.teOn = false
+	return nil
 }
 
 // setWindow defines the output area for subsequent calls to CMD_RAMWR
-func (disp *Ili948x) setWindow(x, y, w, h uint16) {
+func (disp *Ili948x) setWindow(x, y, w, h uint16) error {
 	x1 := x + w - 1
 	if x != disp.x0 || x1 != disp.x1 {
-		disp.writeCmd(CMD_CASET,
+		err := disp.writeCmd(CMD_CASET,
 			uint8(x>>8),
 			uint8(x),
 			uint8(x1>>8),
 			uint8(x1),
 		)
+		if err != nil {
+			return err
+		}
 		disp.x0, disp.x1 = x, x1
 	}
 	y1 := y + h - 1
 	if y != disp.y0 || y1 != disp.y1 {
-		disp.writeCmd(CMD_PASET,
+		err := disp.writeCmd(CMD_PASET,
 			uint8(y>>8),
 			uint8(y),
 			uint8(y1>>8),
 			uint8(y1),
 		)
+		if err != nil {
+			return err
+		}
 		disp.y0, disp.y1 = y, y1
 	}
+	return nil
 }
 
 // updateMadctl updates CMD_MADCTRL based settings (mirror, rotation, RGB/BGR)
-func (disp *Ili948x) updateMadctl() {
+func (disp *Ili948x) updateMadctl() error {
 	madctl := uint8(0)
 
+	// MADCTRL_ML follows the row order of each rotation so scroll, partial
+	// area and tear scan lines count from the top or left of the display,
+	// SetScrollReverse flips it
+
 	if !disp.mirror {
 		// regular
 		switch disp.rot {
@@ -306,82 +597,99 @@ func (disp *Ili948x) updateMadctl() {
 		}
 	}
 
+	if disp.scrollReverse {
+		madctl ^= MADCTRL_ML
+	}
+
 	if disp.bgr {
 		madctl |= MADCTRL_BGR
 	}
 
-	disp.writeCmd(CMD_MADCTRL, madctl)
+	return disp.writeCmd(CMD_MADCTRL, madctl)
 }
 
-// init performs base-level initialization and setup of the TFT display
-func (disp *Ili948x) init() {
-	disp.writeCmd(CMD_PWCTRL1,
-		0x17, // VREG1OUT:  5.0000
-		0x15) // VREG2OUT: -4.8750
-
-	disp.writeCmd(CMD_PWCTRL2,
-		0x41) // VGH: VCI x 6  VGL: -VCI x 4
-
-	disp.writeCmd(CMD_VMCTRL,
-		0x00, // nVM
-		0x12, // VCM_REG:    -1.71875
-		0x80, // VCM_REG_EN: true
-		0x40) // VCM_OUT
-
-	disp.writeCmd(CMD_PIXFMT,
-		0x66) // DPI/DBI: 18 bits / pixel
-
-	disp.writeCmd(CMD_FRMCTRL1,
-		0xa0, // FRS: 60.76  DIVA: 0
-		0x11) // RTNA: 17 clocks
-
-	disp.writeCmd(CMD_INVCTRL,
-		0x02) // DINV: 2 dot inversion
+// writeCmd issues a TFT command with optional data
+func (disp *Ili948x) writeCmd(cmd uint8, data ...uint8) error {
+	disp.startWrite()
 
-	disp.writeCmd(CMD_DISCTRL,
-		0x02, // PT: AGND
-		0x22, // SS: S960 -> S1  ISC: 5 frames
-		0x3b) // NL: 8 * (3b + 1) = 480 lines
+	disp.dc.Low() // command mode
+	err := disp.trans.Write8(cmd)
 
-	disp.writeCmd(CMD_ETMOD,
-		0xc6) // EPF: 11 (db5 -> r0,g0,b0)
+	disp.dc.High() // data mode
+	if err == nil && len(data) > 0 {
+		err = disp.trans.Write8sl(data)
+	}
 
-	disp.writeCmd(CMD_ADJCTRL3,
-		0xa9, //
-		0x51, //
-		0x2c, //
-		0x82) // DSI_18_option:
+	disp.endWrite()
 
-	disp.updateMadctl()
+	return busError("write command", err)
+}
 
-	disp.writeCmd(CMD_SLPOUT)
-	time.Sleep(time.Millisecond * 120)
-	disp.writeCmd(CMD_DISON)
+// readCmd issues a TFT read command and reads its response into data,
+// discarding the leading dummy byte.
+func (disp *Ili948x) readCmd(cmd uint8, data []uint8) error {
+	return disp.readCmdFunc(cmd, func(rt ReadTransport) error {
+		return rt.Read8sl(data)
+	})
 }
 
-// writeCmd issues a TFT command with optional data
-func (disp *Ili948x) writeCmd(cmd uint8, data ...uint8) {
+// readCmdFunc issues a TFT read command, discards the dummy byte and calls
+// read with the bus in read mode.
+func (disp *Ili948x) readCmdFunc(cmd uint8, read func(rt ReadTransport) error) error {
+	rt, ok := disp.trans.(ReadTransport)
+	if !ok {
+		return &Error{Op: "read command", Kind: ErrNotSupported}
+	}
+
 	disp.startWrite()
 
 	disp.dc.Low() // command mode
-	disp.trans.write8(cmd)
+	err := rt.Write8(cmd)
 
 	disp.dc.High() // data mode
-	disp.trans.write8sl(data)
+	if err == nil {
+		err = rt.SetReadMode(true)
+		if err == nil {
+			var dummy [1]uint8
+			err = rt.Read8sl(dummy[:])
+			if err == nil {
+				err = read(rt)
+			}
+			if rerr := rt.SetReadMode(false); err == nil {
+				err = rerr
+			}
+		}
+	}
 
 	disp.endWrite()
+
+	return busError("read command", err)
 }
 
 //go:inline
 func (disp *Ili948x) startWrite() {
-	if disp.cs != machine.NoPin {
+	if disp.cs != nil {
 		disp.cs.Low()
 	}
 }
 
 //go:inline
 func (disp *Ili948x) endWrite() {
-	if disp.cs != machine.NoPin {
+	if disp.cs != nil {
 		disp.cs.High()
 	}
 }
+
+func min32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
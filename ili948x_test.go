@@ -0,0 +1,588 @@
+package ili948x_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/sim"
+	"github.com/inindev/ili948x/trace"
+)
+
+// newRecorded returns an ILI9488 on a trace.Recorder with the init sequence
+// already discarded.
+func newRecorded(t *testing.T) (*ili948x.Ili948x, *trace.Recorder) {
+	t.Helper()
+	rec := trace.NewRecorder()
+	disp, err := ili948x.NewIli9488(rec, nil, rec.DC(), nil, nil, 320, 480)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	return disp, rec
+}
+
+// newSimulated returns a display on a simulated controller.
+func newSimulated(t *testing.T, opts ...ili948x.Option) (*ili948x.Ili948x, *sim.Display) {
+	t.Helper()
+	d := sim.New()
+	disp, err := ili948x.New(d, nil, d.DC(), nil, nil, 0, 0, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return disp, d
+}
+
+// newSimulatedIC returns a display on a simulated controller reporting ic as
+// its RDID4 model.
+func newSimulatedIC(t *testing.T, ic uint16, opts ...ili948x.Option) (*ili948x.Ili948x, *sim.Display) {
+	t.Helper()
+	d := sim.New()
+	d.ID4 = [3]uint8{0x00, uint8(ic >> 8), uint8(ic)}
+	disp, err := ili948x.New(d, nil, d.DC(), nil, nil, 0, 0, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return disp, d
+}
+
+// checkOps compares the recorded ops with want.
+func checkOps(t *testing.T, rec *trace.Recorder, want []trace.Op) {
+	t.Helper()
+	got := rec.Ops()
+	if len(got) != len(want) {
+		t.Fatalf("got ops:\n%swant %d ops", rec, len(want))
+	}
+	for i := range want {
+		if got[i].Cmd != want[i].Cmd || !bytes.Equal(got[i].Params, want[i].Params) {
+			t.Errorf("op %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// pixels returns n pixels of color in wire order.
+func pixels(color uint32, n int) []uint8 {
+	buf := make([]uint8, 0, n*3)
+	for i := 0; i < n; i++ {
+		buf = append(buf, uint8(color), uint8(color>>8), uint8(color>>16))
+	}
+	return buf
+}
+
+func TestFillRectangleStream(t *testing.T) {
+	disp, rec := newRecorded(t)
+
+	if err := disp.FillRectangle(10, 20, 3, 2, 0x112233); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_CASET, Params: []uint8{0x00, 10, 0x00, 12}},
+		{Cmd: ili948x.CMD_PASET, Params: []uint8{0x00, 20, 0x00, 21}},
+		{Cmd: ili948x.CMD_RAMWR, Params: pixels(0x112233, 6)},
+	})
+
+	// the pixel data goes out as 24 bit writes with dc high
+	writes := rec.Writes()
+	last := writes[len(writes)-1]
+	if !last.DC || last.Bits != 24 {
+		t.Errorf("pixel write: dc %v, %d bits, want dc high, 24 bits", last.DC, last.Bits)
+	}
+
+	// coordinates above 255 use both address bytes
+	rec.Reset()
+	if err := disp.FillRectangle(300, 400, 20, 80, 0); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_CASET, Params: []uint8{0x01, 0x2c, 0x01, 0x3f}},
+		{Cmd: ili948x.CMD_PASET, Params: []uint8{0x01, 0x90, 0x01, 0xdf}},
+		{Cmd: ili948x.CMD_RAMWR, Params: pixels(0, 20*80)},
+	})
+}
+
+func TestWindowCache(t *testing.T) {
+	disp, rec := newRecorded(t)
+
+	// the first draw after reset sends the whole window, even the columns
+	// and rows starting and ending at 0
+	if err := disp.FillRectangle(0, 0, 1, 10, 0x112233); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_CASET, Params: []uint8{0x00, 0, 0x00, 0}},
+		{Cmd: ili948x.CMD_PASET, Params: []uint8{0x00, 0, 0x00, 9}},
+		{Cmd: ili948x.CMD_RAMWR, Params: pixels(0x112233, 10)},
+	})
+	if err := disp.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.Wake(); err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	if err := disp.FillRectangle(0, 0, 10, 1, 0x112233); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_CASET, Params: []uint8{0x00, 0, 0x00, 9}},
+		{Cmd: ili948x.CMD_PASET, Params: []uint8{0x00, 0, 0x00, 0}},
+		{Cmd: ili948x.CMD_RAMWR, Params: pixels(0x112233, 10)},
+	})
+	rec.Reset()
+
+	if err := disp.FillRectangle(10, 20, 3, 2, 0x112233); err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+
+	// same window: only the memory write
+	if err := disp.FillRectangle(10, 20, 3, 2, 0x445566); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_RAMWR, Params: pixels(0x445566, 6)},
+	})
+
+	// same columns, new rows: only the page address is sent
+	rec.Reset()
+	if err := disp.FillRectangle(10, 30, 3, 2, 0x445566); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_PASET, Params: []uint8{0x00, 30, 0x00, 31}},
+		{Cmd: ili948x.CMD_RAMWR, Params: pixels(0x445566, 6)},
+	})
+}
+
+func TestFirstDraw(t *testing.T) {
+	disp, d := newSimulated(t)
+	if err := disp.FillRectangle(0, 0, 1, 10, 0xffffff); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []struct{ x, y int }{{0, 0}, {0, 9}, {1, 0}, {9, 0}, {0, 10}} {
+		lit := d.Pixel(p.x, p.y) != 0
+		if want := p.x == 0 && p.y < 10; lit != want {
+			t.Errorf("pixel (%d, %d) lit %v, want %v", p.x, p.y, lit, want)
+		}
+	}
+}
+
+func TestBusError(t *testing.T) {
+	disp, rec := newRecorded(t)
+
+	cause := errors.New("spi failure")
+	rec.SetError(cause)
+	err := disp.FillRectangle(10, 20, 3, 2, 0x112233)
+	if !errors.Is(err, ili948x.ErrBus) {
+		t.Fatalf("got %v, want ErrBus", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("got %v, want it to wrap %v", err, cause)
+	}
+	var e *ili948x.Error
+	if !errors.As(err, &e) || e.Op == "" {
+		t.Errorf("got %#v, want *Error with an op", err)
+	}
+	if len(rec.Writes()) != 0 {
+		t.Errorf("failed writes recorded:\n%s", rec)
+	}
+
+	// a window which failed to be set is not cached
+	rec.SetError(nil)
+	if err := disp.FillRectangle(10, 20, 3, 2, 0x112233); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_CASET, Params: []uint8{0x00, 10, 0x00, 12}},
+		{Cmd: ili948x.CMD_PASET, Params: []uint8{0x00, 20, 0x00, 21}},
+		{Cmd: ili948x.CMD_RAMWR, Params: pixels(0x112233, 6)},
+	})
+}
+
+func TestDisplayerLastErr(t *testing.T) {
+	disp, rec := newRecorded(t)
+	d := ili948x.NewDisplayer(disp)
+
+	d.SetPixel(1, 2, color.RGBA{R: 0xff, A: 0xff})
+	if err := d.LastErr(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec.SetError(errors.New("spi failure"))
+	d.SetPixel(1, 2, color.RGBA{R: 0xff, A: 0xff})
+	rec.SetError(nil)
+	d.SetPixel(3, 4, color.RGBA{G: 0xff, A: 0xff})
+	if err := d.LastErr(); !errors.Is(err, ili948x.ErrBus) {
+		t.Errorf("got %v, want ErrBus", err)
+	}
+	if err := d.LastErr(); err != nil {
+		t.Errorf("got %v after LastErr, want nil", err)
+	}
+
+	rec.SetError(errors.New("spi failure"))
+	d.SetScroll(10)
+	rec.SetError(nil)
+	if err := d.Display(); !errors.Is(err, ili948x.ErrBus) {
+		t.Errorf("Display: got %v, want ErrBus", err)
+	}
+}
+
+// bitmapStream returns a w by h image in the DisplayBitmap stream format and
+// the color of each pixel.
+func bitmapStream(w, h int, bpp uint8) ([]uint8, []uint32) {
+	var data []uint8
+	var colors []uint32
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := uint32(x*8)<<16 | uint32(y*16)<<8 | uint32(0xff-x*4)
+			if bpp == 16 {
+				v := uint16(c>>8)&0xf800 | uint16(c>>5)&0x07e0 | uint16(c>>3)&0x1f
+				data = append(data, uint8(v), uint8(v>>8))
+				c = ili948x.RGB565ToColor(v)
+			} else {
+				data = append(data, uint8(c), uint8(c>>8), uint8(c>>16))
+			}
+			colors = append(colors, c)
+		}
+	}
+	return data, colors
+}
+
+func TestDisplayBitmapShortReads(t *testing.T) {
+	disp, d, fill := simDisplay(t)
+	const w, h = 30, 6
+	for _, bpp := range []uint8{16, 24} {
+		data, colors := bitmapStream(w, h, bpp)
+		for _, pos := range []struct{ x, y int16 }{{10, 10}, {-7, -2}, {300, 477}} {
+			for name, r := range map[string]io.Reader{
+				"one byte": iotest.OneByteReader(bytes.NewReader(data)),
+				"half":     iotest.HalfReader(bytes.NewReader(data)),
+			} {
+				if err := disp.FillScreen(0); err != nil {
+					t.Fatal(err)
+				}
+				if err := disp.DisplayBitmap(pos.x, pos.y, w, h, bpp, r); err != nil {
+					t.Fatal(err)
+				}
+				for y := 0; y < h; y++ {
+					for x := 0; x < w; x++ {
+						dx, dy := int(pos.x)+x, int(pos.y)+y
+						if dx < 0 || dy < 0 || dx >= sim.Width-1 || dy >= sim.Height {
+							continue // off-screen, or the fill probe
+						}
+						if got, want := d.Pixel(dx, dy), fill(colors[y*w+x]); got != want {
+							t.Fatalf("%d bpp %s reads at %v: pixel (%d, %d): got %05x, want %05x",
+								bpp, name, pos, x, y, got, want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDisplayBitmapTruncated(t *testing.T) {
+	disp, rec := newRecorded(t)
+	data, _ := bitmapStream(4, 2, 24)
+
+	// the stream ends one byte into the second pixel of the second row
+	if err := disp.DisplayBitmap(0, 0, 4, 2, 24, bytes.NewReader(data[:4*3+4])); err != nil {
+		t.Fatal(err)
+	}
+	ops := rec.Ops()
+	last := ops[len(ops)-1]
+	if last.Cmd != ili948x.CMD_RAMWR || !bytes.Equal(last.Params, data[:5*3]) {
+		t.Errorf("got %v, want CMD_RAMWR with 5 whole pixels", last)
+	}
+
+	// clipped on the left: no whole visible pixel in the second row
+	rec.Reset()
+	if err := disp.DisplayBitmap(-1, 0, 4, 2, 24, bytes.NewReader(data[:4*3+4])); err != nil {
+		t.Fatal(err)
+	}
+	ops = rec.Ops()
+	last = ops[len(ops)-1]
+	if last.Cmd != ili948x.CMD_RAMWR || !bytes.Equal(last.Params, data[3:4*3]) {
+		t.Errorf("got %v, want CMD_RAMWR with the 3 visible pixels of the first row", last)
+	}
+}
+
+// scrollModes are the rotations and scroll directions of the scroll tests.
+var scrollModes = []struct {
+	rot     ili948x.Rotation
+	reverse bool
+}{
+	{ili948x.Rot_0, false},
+	{ili948x.Rot_0, true},
+	{ili948x.Rot_90, false},
+	{ili948x.Rot_90, true},
+	{ili948x.Rot_180, false},
+	{ili948x.Rot_180, true},
+	{ili948x.Rot_270, false},
+	{ili948x.Rot_270, true},
+}
+
+// panelPoint returns the panel pixel of a display pixel in a rotation.
+func panelPoint(rot ili948x.Rotation, x, y int) (int, int) {
+	switch rot {
+	case ili948x.Rot_90:
+		return sim.Width - 1 - y, x
+	case ili948x.Rot_180:
+		return sim.Width - 1 - x, sim.Height - 1 - y
+	case ili948x.Rot_270:
+		return y, sim.Height - 1 - x
+	}
+	return x, y
+}
+
+// checkImage compares two panel images.
+func checkImage(t *testing.T, name string, got, want *image.RGBA) {
+	t.Helper()
+	for y := 0; y < sim.Height; y++ {
+		for x := 0; x < sim.Width; x++ {
+			if got.RGBAAt(x, y) != want.RGBAAt(x, y) {
+				t.Fatalf("%s: pixel (%d, %d): got %v, want %v", name, x, y, got.RGBAAt(x, y), want.RGBAAt(x, y))
+			}
+		}
+	}
+}
+
+// drawLines fills every line along the gate lines, rows in Rot_0 and Rot_180
+// and columns in Rot_90 and Rot_270, with its own color.
+func drawLines(t *testing.T, disp *ili948x.Ili948x) {
+	t.Helper()
+	w, h := disp.Size()
+	rot := disp.GetRotation()
+	for l := int16(0); l < 480; l++ {
+		c := uint32(l&0x3f)<<10 | uint32(l>>6)<<2
+		x, y, lw, lh := int16(0), l, w, int16(1)
+		if rot == ili948x.Rot_90 || rot == ili948x.Rot_270 {
+			x, y, lw, lh = l, 0, 1, h
+		}
+		if err := disp.FillRectangle(x, y, lw, lh, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScroll(t *testing.T) {
+	const tfa, bfa, k = 10, 20, 7
+	const vsa = 480 - tfa - bfa
+	for _, tt := range scrollModes {
+		disp, d := newSimulated(t)
+		if err := disp.SetRotation(tt.rot); err != nil {
+			t.Fatal(err)
+		}
+		drawLines(t, disp)
+		ref := d.Image()
+
+		// reversing before or after defining the area is the same
+		early := tt.rot == ili948x.Rot_0 || tt.rot == ili948x.Rot_90
+		if tt.reverse && early {
+			if err := disp.SetScrollReverse(true); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := disp.SetScrollArea(tfa, bfa); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.SetScroll(tfa + k); err != nil {
+			t.Fatal(err)
+		}
+		if tt.reverse && !early {
+			if err := disp.SetScrollReverse(true); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := disp.GetScroll(); got != tfa+k {
+			t.Errorf("%v: GetScroll got %d, want %d", tt, got, tfa+k)
+		}
+		if a, b, c := disp.GetScrollArea(); a != tfa || b != vsa || c != bfa {
+			t.Errorf("%v: GetScrollArea got %d, %d, %d, want %d, %d, %d", tt, a, b, c, tfa, vsa, bfa)
+		}
+
+		// point returns the panel pixel of a line, rows or columns counting
+		// from the top or left
+		landscape := tt.rot == ili948x.Rot_90 || tt.rot == ili948x.Rot_270
+		point := func(line int) (int, int) {
+			if landscape {
+				return panelPoint(tt.rot, line, 5)
+			}
+			return panelPoint(tt.rot, 5, line)
+		}
+		img := d.Image()
+		for line := 0; line < 480; line++ {
+			src := line
+			if line >= tfa && line < tfa+vsa {
+				// normally the content moves up or left, reversed the other way
+				off := k
+				if tt.reverse {
+					off = vsa - k
+				}
+				src = tfa + (line-tfa+off)%vsa
+			}
+			x, y := point(line)
+			sx, sy := point(src)
+			if got, want := img.RGBAAt(x, y), ref.RGBAAt(sx, sy); got != want {
+				t.Fatalf("%v: line %d shows %v, want line %d %v", tt, line, got, src, want)
+			}
+		}
+	}
+}
+
+func TestScrollReverseMadctl(t *testing.T) {
+	disp, rec := newRecorded(t)
+	for rot := ili948x.Rot_0; rot <= ili948x.Rot_270; rot++ {
+		for _, mirror := range []bool{false, true} {
+			if err := disp.SetRotation(rot); err != nil {
+				t.Fatal(err)
+			}
+			if err := disp.SetMirror(mirror); err != nil {
+				t.Fatal(err)
+			}
+			ops := rec.Ops()
+			normal := ops[len(ops)-1].Params[0]
+			if err := disp.SetScrollReverse(true); err != nil {
+				t.Fatal(err)
+			}
+			var reversed uint8
+			for _, op := range rec.Ops()[len(ops):] {
+				if op.Cmd == ili948x.CMD_MADCTRL {
+					reversed = op.Params[0]
+				}
+			}
+			if reversed != normal^ili948x.MADCTRL_ML {
+				t.Errorf("rot %d mirror %v: MADCTRL %#x reversed to %#x", rot, mirror, normal, reversed)
+			}
+			if err := disp.SetScrollReverse(false); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestPartialAreaReversed(t *testing.T) {
+	for _, reverse := range []bool{false, true} {
+		disp, d := newSimulated(t)
+		if err := disp.SetScrollReverse(reverse); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.FillScreen(0xffffff); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.SetPartialArea(100, 199); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.EnterPartialMode(); err != nil {
+			t.Fatal(err)
+		}
+		img := d.Image()
+		for _, row := range []int{0, 99, 100, 199, 200, 479} {
+			lit := img.RGBAAt(0, row).R != 0
+			if want := row >= 100 && row <= 199; lit != want {
+				t.Errorf("reverse %v: row %d lit %v, want %v", reverse, row, lit, want)
+			}
+		}
+	}
+}
+
+func TestExitPartialMode(t *testing.T) {
+	disp, d := newSimulated(t)
+	drawLines(t, disp)
+	ref := d.Image()
+	if err := disp.SetScrollArea(20, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.SetScroll(70); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.SetPartialArea(100, 199); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.EnterPartialMode(); err != nil {
+		t.Fatal(err)
+	}
+
+	// normal mode shows the whole frame unscrolled, the scroll line is reset
+	if err := disp.ExitPartialMode(); err != nil {
+		t.Fatal(err)
+	}
+	if got := disp.GetScroll(); got != 20 {
+		t.Errorf("got scroll line %d, want 20", got)
+	}
+	checkImage(t, "normal mode", d.Image(), ref)
+}
+
+func TestSetPartialFrameRate(t *testing.T) {
+	disp, rec := newRecorded(t)
+	if err := disp.SetPartialFrameRate(0x0a, 0x01, 0x11); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.SetPartialFrameRate(0x0f, 0x03, 0x1f); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_FRMCTRL3, Params: []uint8{0xa1, 0x11}},
+		{Cmd: ili948x.CMD_FRMCTRL3, Params: []uint8{0xf3, 0x1f}},
+	})
+
+	rec.Reset()
+	for _, tt := range [][3]uint8{{0x10, 0, 0x10}, {0, 4, 0x10}, {0, 0, 0x0f}, {0, 0, 0x20}} {
+		err := disp.SetPartialFrameRate(tt[0], tt[1], tt[2])
+		if !errors.Is(err, ili948x.ErrOutOfBounds) {
+			t.Errorf("SetPartialFrameRate(%#x, %d, %#x): got %v, want ErrOutOfBounds", tt[0], tt[1], tt[2], err)
+		}
+	}
+	checkOps(t, rec, nil)
+}
+
+func TestReadRectangleErrors(t *testing.T) {
+	disp, _ := newSimulated(t)
+	buf := make([]uint32, 4)
+	for _, tt := range []struct {
+		name                string
+		x, y, width, height int16
+		err                 error
+	}{
+		{"left", -1, 0, 2, 2, ili948x.ErrOutOfBounds},
+		{"bottom", 0, 479, 2, 2, ili948x.ErrOutOfBounds},
+		{"empty", 0, 0, 0, 2, ili948x.ErrOutOfBounds},
+		{"short buffer", 0, 0, 3, 2, io.ErrShortBuffer},
+	} {
+		if err := disp.ReadRectangle(tt.x, tt.y, tt.width, tt.height, buf); !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+// writeOnly hides the read methods of a transport.
+type writeOnly struct {
+	ili948x.Transport
+}
+
+func TestReadWriteOnly(t *testing.T) {
+	rec := trace.NewRecorder()
+	disp, err := ili948x.NewIli9488(writeOnly{rec}, nil, rec.DC(), nil, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	for name, read := range map[string]func() error{
+		"ReadRectangle": func() error { return disp.ReadRectangle(0, 0, 1, 1, make([]uint32, 1)) },
+		"Screenshot":    func() error { return disp.Screenshot(io.Discard) },
+		"DrawTextOver":  func() error { return disp.DrawTextOver(0, 0, "a", ili948x.Font7x13, 0xffffff) },
+		"ReadID":        func() error { _, err := disp.ReadID(); return err },
+	} {
+		if err := read(); !errors.Is(err, ili948x.ErrNotSupported) {
+			t.Errorf("%s: got %v, want ErrNotSupported", name, err)
+		}
+	}
+	if ops := rec.Ops(); len(ops) != 0 {
+		t.Errorf("wrote %v", ops)
+	}
+}
@@ -0,0 +1,181 @@
+package ili948x
+
+import (
+	"time"
+)
+
+// InitCmd is a command of an init sequence with its parameters, followed by
+// an optional delay.
+type InitCmd struct {
+	Cmd    uint8
+	Params []uint8
+	Delay  time.Duration
+}
+
+// InitSequence is a table of commands initializing a controller after reset.
+// The interface pixel format (CMD_PIXFMT) and memory access control
+// (CMD_MADCTRL) are written by the driver before the sequence runs and
+// should not be part of it. A sequence normally takes the display out of
+// sleep and turns it on; the state reported by GetPowerState follows the
+// sleep, idle and display on/off commands it contains.
+type InitSequence []InitCmd
+
+// ProfileILI9488 is the generic ILI9488 init sequence.
+var ProfileILI9488 = InitSequence{
+	{Cmd: CMD_PWCTRL1, Params: []uint8{
+		0x17, // VREG1OUT:  5.0000
+		0x15, // VREG2OUT: -4.8750
+	}},
+	{Cmd: CMD_PWCTRL2, Params: []uint8{
+		0x41, // VGH: VCI x 6  VGL: -VCI x 4
+	}},
+	{Cmd: CMD_VMCTRL, Params: []uint8{
+		0x00, // nVM
+		0x12, // VCM_REG:    -1.71875
+		0x80, // VCM_REG_EN: true
+		0x40, // VCM_OUT
+	}},
+	{Cmd: CMD_FRMCTRL1, Params: []uint8{
+		0xa0, // FRS: 60.76  DIVA: 0
+		0x11, // RTNA: 17 clocks
+	}},
+	{Cmd: CMD_INVCTRL, Params: []uint8{
+		0x02, // DINV: 2 dot inversion
+	}},
+	{Cmd: CMD_DISCTRL, Params: []uint8{
+		0x02, // PT: AGND
+		0x22, // SS: S960 -> S1  ISC: 5 frames
+		0x3b, // NL: 8 * (3b + 1) = 480 lines
+	}},
+	{Cmd: CMD_ETMOD, Params: []uint8{
+		0xc6, // EPF: 11 (db5 -> r0,g0,b0)
+	}},
+	{Cmd: CMD_ADJCTRL3, Params: []uint8{
+		0xa9, //
+		0x51, //
+		0x2c, //
+		0x82, // DSI_18_option:
+	}},
+	{Cmd: CMD_SLPOUT, Delay: time.Millisecond * 120},
+	{Cmd: CMD_DISON},
+}
+
+// ProfileMakerfabsESP32C3 is the init sequence of the Makerfabs ESP32-C3
+// 3.5" SPI module (ILI9488), adding the panel vendor's gamma curves.
+var ProfileMakerfabsESP32C3 = InitSequence{
+	{Cmd: CMD_PGAMCTRL, Params: GammaMakerfabsESP32C3.Positive[:]},
+	{Cmd: CMD_NGAMCTRL, Params: GammaMakerfabsESP32C3.Negative[:]},
+	{Cmd: CMD_PWCTRL1, Params: []uint8{
+		0x17, // VREG1OUT:  5.0000
+		0x15, // VREG2OUT: -4.8750
+	}},
+	{Cmd: CMD_PWCTRL2, Params: []uint8{
+		0x41, // VGH: VCI x 6  VGL: -VCI x 4
+	}},
+	{Cmd: CMD_VMCTRL, Params: []uint8{
+		0x00, // nVM
+		0x12, // VCM_REG:    -1.71875
+		0x80, // VCM_REG_EN: true
+	}},
+	{Cmd: CMD_IFMODE, Params: []uint8{
+		0x00, // SDA_EN: DIN / DOUT pins used
+	}},
+	{Cmd: CMD_FRMCTRL1, Params: []uint8{
+		0xa0, // FRS: 60.76  DIVA: 0
+	}},
+	{Cmd: CMD_INVCTRL, Params: []uint8{
+		0x02, // DINV: 2 dot inversion
+	}},
+	{Cmd: CMD_DISCTRL, Params: []uint8{
+		0x02, // PT: AGND
+		0x22, // SS: S960 -> S1  ISC: 5 frames
+		0x3b, // NL: 8 * (3b + 1) = 480 lines
+	}},
+	{Cmd: CMD_ETMOD, Params: []uint8{
+		0xc6, // EPF: 11 (db5 -> r0,g0,b0)
+	}},
+	{Cmd: CMD_ADJCTRL3, Params: []uint8{
+		0xa9, //
+		0x51, //
+		0x2c, //
+		0x82, // DSI_18_option:
+	}},
+	{Cmd: CMD_SLPOUT, Delay: time.Millisecond * 120},
+	{Cmd: CMD_DISON},
+}
+
+// ProfileWaveshareILI9486 is the init sequence of the Waveshare 3.5" ILI9486
+// modules.
+var ProfileWaveshareILI9486 = InitSequence{
+	{Cmd: CMD_SLPOUT, Delay: time.Millisecond * 120},
+	{Cmd: CMD_PWCTRL1, Params: []uint8{
+		0x0e, // VRH1: 4.4375
+		0x0e, // VRH2: 4.4375
+	}},
+	{Cmd: CMD_PWCTRL2, Params: []uint8{
+		0x41, // BT: VGH: VCI x 6  VGL: -VCI x 4
+		0x00, // VC: 1.0 x VCI
+	}},
+	{Cmd: CMD_PWCTRL3, Params: []uint8{
+		0x55, // DCA0, DCA1: step-up circuit frequencies
+	}},
+	{Cmd: CMD_VMCTRL, Params: []uint8{
+		0x00, // nVM
+		0x00, // VCM_REG
+		0x00, // VCM_REG_EN: false
+		0x00, // VCM_OUT
+	}},
+	{Cmd: CMD_PGAMCTRL, Params: GammaWaveshareILI9486.Positive[:]},
+	{Cmd: CMD_NGAMCTRL, Params: GammaWaveshareILI9486.Negative[:]},
+	{Cmd: CMD_INVOFF},
+	{Cmd: CMD_DISON},
+}
+
+// WithInitSequence replaces the built-in init sequence of the controller,
+// e.g. with one of the Profile* sequences or a panel vendor's table.
+func WithInitSequence(seq InitSequence) Option {
+	return func(disp *Ili948x) {
+		disp.initSeq = seq
+	}
+}
+
+// init performs base-level initialization and setup of the TFT display
+func (disp *Ili948x) init() error {
+	seq := disp.initSeq
+	if seq == nil {
+		seq = ProfileILI9488
+		if disp.id.Controller == ILI9486 {
+			seq = ProfileWaveshareILI9486
+		}
+	}
+
+	if err := disp.writeCmd(CMD_PIXFMT, uint8(disp.pixfmt)); err != nil {
+		return err
+	}
+	if err := disp.updateMadctl(); err != nil {
+		return err
+	}
+
+	// the power state starts from the one Reset left
+	for _, c := range seq {
+		if err := disp.writeCmd(c.Cmd, c.Params...); err != nil {
+			return err
+		}
+		disp.power.update(c.Cmd)
+		if c.Delay > 0 {
+			time.Sleep(c.Delay)
+		}
+	}
+
+	if disp.te != nil {
+		disp.teLine = 0
+		if err := disp.SetTearingEffect(true); err != nil {
+			return err
+		}
+	}
+
+	if disp.gamma != nil {
+		return disp.SetGamma(*disp.gamma)
+	}
+	return nil
+}
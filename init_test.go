@@ -0,0 +1,59 @@
+package ili948x_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/trace"
+)
+
+func TestInitPowerState(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		seq  ili948x.InitSequence
+		want ili948x.PowerState
+	}{
+		{"default", nil, ili948x.PowerState{DisplayOn: true}},
+		{"empty", ili948x.InitSequence{}, ili948x.PowerState{Asleep: true}},
+		{"awake", ili948x.InitSequence{
+			{Cmd: ili948x.CMD_SLPOUT},
+		}, ili948x.PowerState{}},
+		{"on", ili948x.InitSequence{
+			{Cmd: ili948x.CMD_SLPOUT},
+			{Cmd: ili948x.CMD_IDMON},
+			{Cmd: ili948x.CMD_DISON},
+		}, ili948x.PowerState{Idle: true, DisplayOn: true}},
+		{"off again", ili948x.InitSequence{
+			{Cmd: ili948x.CMD_SLPOUT},
+			{Cmd: ili948x.CMD_IDMON},
+			{Cmd: ili948x.CMD_DISON},
+			{Cmd: ili948x.CMD_IDMOFF},
+			{Cmd: ili948x.CMD_DISOFF},
+			{Cmd: ili948x.CMD_SLPIN},
+		}, ili948x.PowerState{Asleep: true}},
+		{"reset", ili948x.InitSequence{
+			{Cmd: ili948x.CMD_SLPOUT},
+			{Cmd: ili948x.CMD_DISON},
+			{Cmd: ili948x.CMD_SWRESET},
+		}, ili948x.PowerState{Asleep: true}},
+	} {
+		rec := trace.NewRecorder()
+		var opts []ili948x.Option
+		if tt.seq != nil {
+			opts = append(opts, ili948x.WithInitSequence(tt.seq))
+		}
+		disp, err := ili948x.NewIli9488(rec, nil, rec.DC(), nil, nil, 0, 0, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := disp.GetPowerState(); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+		// drawing follows the sleep state
+		err = disp.FillRectangle(0, 0, 1, 1, 0)
+		if asleep := errors.Is(err, ili948x.ErrAsleep); asleep != tt.want.Asleep {
+			t.Errorf("%s: draw returned %v", tt.name, err)
+		}
+	}
+}
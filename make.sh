@@ -4,6 +4,7 @@ set -e
 #BOARD=xiao-esp32c3
 BOARD=makerfabs-esp32c3spi35
 BIN=firmware.bin
+EXAMPLE=${1:-demo}
 
 MYFILE=$(readlink -f "$0")
 MYDIR=$(dirname "${MYFILE}")
@@ -11,7 +12,7 @@ MYDIR=$(dirname "${MYFILE}")
 cd "$MYDIR"
 
 rm -f $BIN
-tinygo build -target=$BOARD -o $BIN .
+tinygo build -target=$BOARD -o $BIN ./examples/$EXAMPLE
 esptool.py -p /dev/cu.usbmodem* -b 921600 write_flash 0 $BIN
 
 #tinygo monitor -port /dev/cu.usbserial*
@@ -0,0 +1,50 @@
+package ili948x
+
+// SetPartialArea defines the band shown in partial mode, from startRow to
+// endRow inclusive. Like Size, the band follows the rotation: the panel's gate
+// lines are rows in Rot_0 and Rot_180 and columns in Rot_90 and Rot_270, so
+// the band spans the full width or the full height of the display. Rows count
+// from the top or left, also with the scroll direction reversed. A start
+// after the end wraps the band around the panel edge.
+func (disp *Ili948x) SetPartialArea(startRow, endRow uint16) error {
+	if startRow >= disp.height || endRow >= disp.height {
+		return &Error{Op: "SetPartialArea", Kind: ErrOutOfBounds}
+	}
+	// the MADCTRL_ML scan direction of every rotation keeps logical lines
+	// and partial area lines in the same order, unless reversed
+	if disp.scrollReverse {
+		startRow, endRow = disp.gateLine(endRow), disp.gateLine(startRow)
+	}
+	return disp.writeCmd(CMD_PLTAR,
+		uint8(startRow>>8),
+		uint8(startRow),
+		uint8(endRow>>8),
+		uint8(endRow))
+}
+
+// EnterPartialMode shows only the partial area set with SetPartialArea, the
+// rest of the panel is blanked. Drawing is not restricted to the area.
+func (disp *Ili948x) EnterPartialMode() error {
+	return disp.writeCmd(CMD_PTLON)
+}
+
+// ExitPartialMode returns the display to normal mode with CMD_NORON, which
+// also ends vertical scrolling: like StopScroll it resets the scroll line to
+// the start of the scroll area, so call SetScroll again to resume scrolling.
+func (disp *Ili948x) ExitPartialMode() error {
+	return disp.StopScroll()
+}
+
+// SetPartialFrameRate sets the CMD_FRMCTRL3 frame rate of partial mode: the
+// frame rate select frs (0-15, 0x0a for the 60.76Hz of normal mode), the
+// internal clock division div (0-3, fosc / 2^div) and the clocks per line rtn
+// (16-31). Lowering the frame rate saves power while a status band is shown.
+func (disp *Ili948x) SetPartialFrameRate(frs, div, rtn uint8) error {
+	if frs > 0x0f || div > 0x03 || rtn < 0x10 || rtn > 0x1f {
+		return &Error{Op: "SetPartialFrameRate", Kind: ErrOutOfBounds}
+	}
+	return disp.writeCmd(CMD_FRMCTRL3,
+		frs<<4|div, // FRS  DIVC
+		rtn,        // RTNC
+	)
+}
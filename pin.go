@@ -0,0 +1,22 @@
+package ili948x
+
+// Pin is a digital output used for the chip select, data/command, backlight
+// and reset lines. machine.Pin satisfies this interface.
+type Pin interface {
+	Set(high bool)
+	High()
+	Low()
+}
+
+// DimmablePin is a backlight Pin which can also be driven at a brightness
+// level, e.g. a PWM channel. Level 0 is off and 255 is fully on.
+type DimmablePin interface {
+	Pin
+	SetLevel(level uint8)
+}
+
+// InputPin is a digital input, such as the tearing effect line.
+// machine.Pin satisfies this interface.
+type InputPin interface {
+	Get() bool
+}
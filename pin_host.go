@@ -0,0 +1,59 @@
+//go:build !tinygo
+
+package ili948x
+
+// FakePin is a Pin for host builds which records the level it is driven to.
+type FakePin struct {
+	level bool
+	Edges int // number of level changes
+}
+
+// NewFakePin returns a FakePin starting at the given level.
+func NewFakePin(high bool) *FakePin {
+	return &FakePin{level: high}
+}
+
+func (p *FakePin) Set(high bool) {
+	if p.level != high {
+		p.Edges++
+	}
+	p.level = high
+}
+
+func (p *FakePin) High() {
+	p.Set(true)
+}
+
+func (p *FakePin) Low() {
+	p.Set(false)
+}
+
+// Get returns the current level of the pin.
+func (p *FakePin) Get() bool {
+	return p.level
+}
+
+// FakePWMPin is a DimmablePin for host builds which records its level.
+type FakePWMPin struct {
+	Level uint8
+}
+
+func (p *FakePWMPin) Set(high bool) {
+	if high {
+		p.Level = 0xff
+	} else {
+		p.Level = 0
+	}
+}
+
+func (p *FakePWMPin) High() {
+	p.Set(true)
+}
+
+func (p *FakePWMPin) Low() {
+	p.Set(false)
+}
+
+func (p *FakePWMPin) SetLevel(level uint8) {
+	p.Level = level
+}
@@ -0,0 +1,139 @@
+package ili948x
+
+import "testing"
+
+// busWrite is a write seen by fakeTransport with the pin levels at the time.
+type busWrite struct {
+	cmd         bool // dc low: command byte
+	data        []uint8
+	cs, bl, rst bool
+}
+
+// pinSet holds the control pins sampled by fakeTransport, nil if not connected.
+type pinSet struct {
+	cs, dc, bl, rst *FakePin
+}
+
+// fakeTransport is a Transport recording each write with the levels of the
+// control pins.
+type fakeTransport struct {
+	pins   pinSet
+	writes []busWrite
+}
+
+func (t *fakeTransport) record(data []uint8) error {
+	w := busWrite{cmd: !t.pins.dc.Get(), data: data, cs: true, bl: true, rst: true}
+	if t.pins.cs != nil {
+		w.cs = t.pins.cs.Get()
+	}
+	if t.pins.bl != nil {
+		w.bl = t.pins.bl.Get()
+	}
+	if t.pins.rst != nil {
+		w.rst = t.pins.rst.Get()
+	}
+	t.writes = append(t.writes, w)
+	return nil
+}
+
+func (t *fakeTransport) Write8(b uint8) error { return t.record([]uint8{b}) }
+func (t *fakeTransport) Write8n(b uint8, n int) error {
+	return t.record(make([]uint8, n))
+}
+func (t *fakeTransport) Write8sl(b []uint8) error { return t.record(append([]uint8(nil), b...)) }
+func (t *fakeTransport) Write16(v uint16) error {
+	return t.record([]uint8{uint8(v), uint8(v >> 8)})
+}
+func (t *fakeTransport) Write16n(v uint16, n int) error { return t.record(make([]uint8, 2*n)) }
+func (t *fakeTransport) Write16sl(v []uint16) error     { return t.record(make([]uint8, 2*len(v))) }
+func (t *fakeTransport) Write24(v uint32) error {
+	return t.record([]uint8{uint8(v), uint8(v >> 8), uint8(v >> 16)})
+}
+func (t *fakeTransport) Write24n(v uint32, n int) error { return t.record(make([]uint8, 3*n)) }
+func (t *fakeTransport) Write24sl(v []uint32) error     { return t.record(make([]uint8, 3*len(v))) }
+
+// commands returns the command bytes written.
+func (t *fakeTransport) commands() []uint8 {
+	var cmds []uint8
+	for _, w := range t.writes {
+		if w.cmd {
+			cmds = append(cmds, w.data...)
+		}
+	}
+	return cmds
+}
+
+func TestNewPinSequencing(t *testing.T) {
+	pins := pinSet{
+		cs:  NewFakePin(false),
+		dc:  NewFakePin(false),
+		bl:  NewFakePin(true),
+		rst: NewFakePin(false),
+	}
+	trans := &fakeTransport{pins: pins}
+
+	disp, err := NewIli9488(trans, pins.cs, pins.dc, pins.bl, pins.rst, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rst: released, pulsed low for the hardware reset, released
+	if !pins.rst.Get() || pins.rst.Edges != 3 {
+		t.Errorf("rst: level %v, %d edges, want high after 3 edges", pins.rst.Get(), pins.rst.Edges)
+	}
+	// bl: off while initializing, then on
+	if !pins.bl.Get() || pins.bl.Edges != 2 {
+		t.Errorf("bl: level %v, %d edges, want high after 2 edges", pins.bl.Get(), pins.bl.Edges)
+	}
+	if !pins.cs.Get() {
+		t.Error("cs: not released after init")
+	}
+	if got := disp.GetBrightness(); got != 0xff {
+		t.Errorf("brightness %#x, want 0xff", got)
+	}
+
+	if len(trans.writes) == 0 {
+		t.Fatal("nothing written")
+	}
+	for i, w := range trans.writes {
+		if !w.rst || w.bl || w.cs {
+			t.Fatalf("write %d: rst %v bl %v cs %v, want rst high, bl low, cs low", i, w.rst, w.bl, w.cs)
+		}
+	}
+	// a hardware reset replaces CMD_SWRESET
+	for _, c := range trans.commands() {
+		if c == CMD_SWRESET {
+			t.Error("CMD_SWRESET sent with a reset pin")
+		}
+	}
+}
+
+func TestNewNilPins(t *testing.T) {
+	pins := pinSet{dc: NewFakePin(false)}
+	trans := &fakeTransport{pins: pins}
+
+	disp, err := NewIli9488(trans, nil, pins.dc, nil, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without a reset pin the display is reset with CMD_SWRESET
+	cmds := trans.commands()
+	if len(cmds) == 0 || cmds[0] != CMD_SWRESET {
+		t.Errorf("first command %x, want CMD_SWRESET", cmds)
+	}
+	if n := len(cmds); cmds[n-1] != CMD_DISON {
+		t.Errorf("last command %#x, want CMD_DISON", cmds[n-1])
+	}
+
+	// not connected backlight and chip select pins are skipped
+	if err := disp.SetBacklight(false); err != nil {
+		t.Error(err)
+	}
+	if err := disp.FillRectangle(0, 0, 2, 2, 0xff0000); err != nil {
+		t.Error(err)
+	}
+	if !pins.dc.Get() {
+		t.Error("dc: not left high for data")
+	}
+}
@@ -0,0 +1,84 @@
+//go:build tinygo
+
+package ili948x
+
+import (
+	"machine"
+)
+
+// OutputPin configures a machine pin as an output and returns it as a Pin.
+// machine.NoPin is returned as nil (not connected).
+func OutputPin(p machine.Pin) Pin {
+	if p == machine.NoPin {
+		return nil
+	}
+	p.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	return p
+}
+
+// NewInputPin configures a machine pin as an input and returns it as an
+// InputPin. machine.NoPin is returned as nil (not connected).
+func NewInputPin(p machine.Pin) InputPin {
+	if p == machine.NoPin {
+		return nil
+	}
+	p.Configure(machine.PinConfig{Mode: machine.PinInput})
+	return p
+}
+
+// PWM is a tinygo PWM peripheral, e.g. machine.PWM0 or machine.TCC0.
+type PWM interface {
+	Configure(config machine.PWMConfig) error
+	Channel(pin machine.Pin) (uint8, error)
+	Top() uint32
+	Set(channel uint8, value uint32)
+}
+
+// backlightPeriod is the PWM period of a dimmable backlight in nanoseconds,
+// 10kHz keeps it above the audible range of the LED driver.
+const backlightPeriod = 1e9 / 10000
+
+// PWMPin configures p as a channel of pwm and returns it as a DimmablePin,
+// for use as a dimmable backlight. The pin starts off.
+// machine.NoPin is returned as nil (not connected).
+func PWMPin(pwm PWM, p machine.Pin) (DimmablePin, error) {
+	if p == machine.NoPin {
+		return nil, nil
+	}
+	if err := pwm.Configure(machine.PWMConfig{Period: backlightPeriod}); err != nil {
+		return nil, err
+	}
+	ch, err := pwm.Channel(p)
+	if err != nil {
+		return nil, err
+	}
+	pin := &pwmPin{pwm: pwm, ch: ch}
+	pin.Low()
+	return pin, nil
+}
+
+// pwmPin is a PWM channel driving a pin.
+type pwmPin struct {
+	pwm PWM
+	ch  uint8
+}
+
+func (p *pwmPin) Set(high bool) {
+	if high {
+		p.High()
+	} else {
+		p.Low()
+	}
+}
+
+func (p *pwmPin) High() {
+	p.SetLevel(0xff)
+}
+
+func (p *pwmPin) Low() {
+	p.SetLevel(0)
+}
+
+func (p *pwmPin) SetLevel(level uint8) {
+	p.pwm.Set(p.ch, uint32(uint64(p.pwm.Top())*uint64(level)/0xff))
+}
@@ -0,0 +1,78 @@
+package ili948x
+
+// PixelFormat is the CMD_PIXFMT interface pixel format.
+type PixelFormat uint8
+
+const (
+	PixelFormat16 PixelFormat = 0x55 // 16 bits / pixel rgb565, 2 bytes (ILI9486)
+	PixelFormat18 PixelFormat = 0x66 // 18 bits / pixel, 3 bytes
+)
+
+// GetPixelFormat returns the interface pixel format in use.
+func (disp *Ili948x) GetPixelFormat() PixelFormat {
+	return disp.pixfmt
+}
+
+// SetPixelFormat switches the interface pixel format. The 16 bit format cuts
+// bus traffic by a third but is only available on the ILI9486, the ILI9488
+// does not accept it over SPI.
+func (disp *Ili948x) SetPixelFormat(pixfmt PixelFormat) error {
+	switch {
+	case pixfmt == PixelFormat18:
+	case pixfmt == PixelFormat16 && disp.id.Controller == ILI9486:
+	default:
+		return &Error{Op: "SetPixelFormat", Kind: ErrNotSupported}
+	}
+	if err := disp.writeCmd(CMD_PIXFMT, uint8(pixfmt)); err != nil {
+		return err
+	}
+	disp.pixfmt = pixfmt
+	return nil
+}
+
+// RGB565ToColor converts an rgb565 pixel to a 0xrrggbb color.
+func RGB565ToColor(v uint16) uint32 {
+	r, g, b := uint32(v>>11), uint32(v>>5)&0x3f, uint32(v)&0x1f
+	return (r<<3|r>>2)<<16 | (g<<2|g>>4)<<8 | (b<<3 | b>>2)
+}
+
+// pixel16 converts a 0xrrggbb color to a 16 bit pixel. The channels are in the
+// order the 18 bit format sends them (least significant first) and the bytes are
+// swapped as the controller expects the most significant byte first.
+func pixel16(c uint32) uint16 {
+	v := uint16(c&0xf8)<<8 | uint16(c>>5)&0x07e0 | uint16(c>>19)&0x1f
+	return v<<8 | v>>8
+}
+
+// fillPixels writes n pixels of a single color after CMD_RAMWR.
+func (disp *Ili948x) fillPixels(color uint32, n int) error {
+	var err error
+	disp.startWrite()
+	if disp.pixfmt == PixelFormat16 {
+		err = disp.trans.Write16n(pixel16(color), n)
+	} else {
+		err = disp.trans.Write24n(color, n)
+	}
+	disp.endWrite()
+	return busError("write pixels", err)
+}
+
+// writePixels writes colors after CMD_RAMWR, converting them to the pixel format.
+func (disp *Ili948x) writePixels(colors []uint32) error {
+	var err error
+	disp.startWrite()
+	if disp.pixfmt == PixelFormat16 {
+		if cap(disp.buf16) < len(colors) {
+			disp.buf16 = make([]uint16, len(colors))
+		}
+		buf := disp.buf16[:len(colors)]
+		for i, c := range colors {
+			buf[i] = pixel16(c)
+		}
+		err = disp.trans.Write16sl(buf)
+	} else {
+		err = disp.trans.Write24sl(colors)
+	}
+	disp.endWrite()
+	return busError("write pixels", err)
+}
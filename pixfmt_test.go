@@ -0,0 +1,148 @@
+package ili948x_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/trace"
+)
+
+// pixfmt returns the last CMD_PIXFMT parameter of ops, 0 if there is none.
+func pixfmt(ops []trace.Op) uint8 {
+	var f uint8
+	for _, op := range ops {
+		if op.Cmd == ili948x.CMD_PIXFMT {
+			f = op.Params[0]
+		}
+	}
+	return f
+}
+
+func TestNewIli9486(t *testing.T) {
+	rec := trace.NewRecorder()
+	disp, err := ili948x.NewIli9486(rec, nil, rec.DC(), nil, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := disp.ID(); id.Controller != ili948x.ILI9486 || id.IC != 0x9486 {
+		t.Errorf("got ID %+v", id)
+	}
+	if f := disp.GetPixelFormat(); f != ili948x.PixelFormat16 {
+		t.Errorf("got pixel format %#02x, want %#02x", f, ili948x.PixelFormat16)
+	}
+	if f := pixfmt(rec.Ops()); f != 0x55 {
+		t.Errorf("init set pixel format %#02x, want 0x55", f)
+	}
+
+	// rgb565 with the most significant byte first, channels in the order of
+	// the 18 bit format: 0x56 0x34 0x12 is 01010 001101 00010
+	rec.Reset()
+	if err := disp.FillRectangle(0, 0, 2, 1, 0x123456); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.DisplayBitmap(0, 1, 2, 1, 24, bytes.NewReader([]uint8{0x56, 0x34, 0x12, 0xff, 0x00, 0x80})); err != nil {
+		t.Fatal(err)
+	}
+	checkOps(t, rec, []trace.Op{
+		{Cmd: ili948x.CMD_CASET, Params: []uint8{0, 0, 0, 1}},
+		{Cmd: ili948x.CMD_PASET, Params: []uint8{0, 0, 0, 0}},
+		{Cmd: ili948x.CMD_RAMWR, Params: []uint8{0x51, 0xa2, 0x51, 0xa2}},
+		{Cmd: ili948x.CMD_PASET, Params: []uint8{0, 1, 0, 1}},
+		{Cmd: ili948x.CMD_RAMWR, Params: []uint8{0x51, 0xa2, 0xf8, 0x10}},
+	})
+}
+
+func TestSetPixelFormat(t *testing.T) {
+	disp, rec := newRecorded(t)
+	err := disp.SetPixelFormat(ili948x.PixelFormat16)
+	if !errors.Is(err, ili948x.ErrNotSupported) {
+		t.Errorf("ILI9488: got %v, want ErrNotSupported", err)
+	}
+	if f := disp.GetPixelFormat(); f != ili948x.PixelFormat18 {
+		t.Errorf("ILI9488: pixel format changed to %#02x", f)
+	}
+	if ops := rec.Ops(); len(ops) != 0 {
+		t.Errorf("ILI9488: wrote %v", ops)
+	}
+	if err := disp.SetPixelFormat(0x77); !errors.Is(err, ili948x.ErrNotSupported) {
+		t.Errorf("bad format: got %v, want ErrNotSupported", err)
+	}
+
+	rec = trace.NewRecorder()
+	disp, err = ili948x.NewIli9486(rec, nil, rec.DC(), nil, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []ili948x.PixelFormat{ili948x.PixelFormat18, ili948x.PixelFormat16} {
+		rec.Reset()
+		if err := disp.SetPixelFormat(f); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.FillRectangle(0, 0, 1, 1, 0x123456); err != nil {
+			t.Fatal(err)
+		}
+		want := []uint8{0x56, 0x34, 0x12}
+		if f == ili948x.PixelFormat16 {
+			want = []uint8{0x51, 0xa2}
+		}
+		ops := rec.Ops()
+		if pixfmt(ops) != uint8(f) || disp.GetPixelFormat() != f || !bytes.Equal(ops[len(ops)-1].Params, want) {
+			t.Errorf("ILI9486 %#02x: wrote %v", f, ops)
+		}
+	}
+}
+
+// exact16 are 8 bit red and blue levels the 16 bit and 18 bit formats store
+// alike: rgb565 expands 5 bits to 6 by repeating the top bit.
+var exact16 = []uint8{0x00, 0x40, 0x84, 0xbc, 0xfc}
+
+func TestPixelFormat16Frame(t *testing.T) {
+	disp16, d16 := newSimulatedIC(t, 0x9486)
+	disp18, d18 := newSimulatedIC(t, 0x9488)
+	if disp16.GetPixelFormat() != ili948x.PixelFormat16 || disp18.GetPixelFormat() != ili948x.PixelFormat18 {
+		t.Fatalf("got pixel formats %#02x and %#02x", disp16.GetPixelFormat(), disp18.GetPixelFormat())
+	}
+
+	// 24 bpp input of levels both formats hold, every green level
+	var bmp24 []uint8
+	for i := 0; i < 64; i++ {
+		bmp24 = append(bmp24, exact16[i%len(exact16)], uint8(i<<2), exact16[i/len(exact16)%len(exact16)])
+	}
+	// 16 bpp input of any value, little endian words
+	var bmp16 []uint8
+	for i := 0; i < 64; i++ {
+		v := uint16(i * 1031)
+		bmp16 = append(bmp16, uint8(v), uint8(v>>8))
+	}
+	for _, disp := range []*ili948x.Ili948x{disp16, disp18} {
+		if err := disp.FillRectangle(0, 0, 20, 5, 0x84bc40); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.DisplayBitmap(0, 5, 16, 4, 24, bytes.NewReader(bmp24)); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.DisplayBitmap(0, 9, 16, 4, 16, bytes.NewReader(bmp16)); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.DrawText(0, 13, "16", ili948x.Font7x13, 0xfc00fc, 0x40fc00); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for y := 0; y < 26; y++ {
+		for x := 0; x < 20; x++ {
+			if got, want := d16.Pixel(x, y), d18.Pixel(x, y); got != want {
+				t.Fatalf("pixel (%d, %d): got %#05x in 16 bits, %#05x in 18 bits", x, y, got, want)
+			}
+		}
+	}
+
+	// other levels lose their low bits
+	if err := disp16.FillRectangle(0, 0, 1, 1, 0x0b0b0b); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d16.Pixel(0, 0), uint32(0x02<<12|0x02<<6|0x02); got != want {
+		t.Errorf("0x0b0b0b: got %#05x, want %#05x", got, want)
+	}
+}
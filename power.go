@@ -0,0 +1,92 @@
+package ili948x
+
+import (
+	"time"
+)
+
+// PowerState is the power state of the controller.
+type PowerState struct {
+	Asleep    bool // sleep in: oscillator and panel scanning stopped
+	Idle      bool // idle mode: 8 colors
+	DisplayOn bool // frame memory shown on the panel
+}
+
+// update tracks a command sent to the controller.
+func (p *PowerState) update(cmd uint8) {
+	switch cmd {
+	case CMD_SWRESET:
+		*p = PowerState{Asleep: true}
+	case CMD_SLPIN:
+		p.Asleep = true
+	case CMD_SLPOUT:
+		p.Asleep = false
+	case CMD_IDMON:
+		p.Idle = true
+	case CMD_IDMOFF:
+		p.Idle = false
+	case CMD_DISON:
+		p.DisplayOn = true
+	case CMD_DISOFF:
+		p.DisplayOn = false
+	}
+}
+
+// GetPowerState returns the current power state.
+func (disp *Ili948x) GetPowerState() PowerState {
+	return disp.power
+}
+
+// Sleep puts the controller in sleep mode, keeping the frame memory. Drawing
+// fails with ErrAsleep until Wake is called. The backlight is left as is.
+func (disp *Ili948x) Sleep() error {
+	if disp.power.Asleep {
+		return nil
+	}
+	if err := disp.writeCmd(CMD_SLPIN); err != nil {
+		return err
+	}
+	disp.power.Asleep = true
+	time.Sleep(time.Millisecond * 5) // datasheet: 5ms before the next command
+	return nil
+}
+
+// Wake takes the controller out of sleep mode.
+func (disp *Ili948x) Wake() error {
+	if !disp.power.Asleep {
+		return nil
+	}
+	if err := disp.writeCmd(CMD_SLPOUT); err != nil {
+		return err
+	}
+	disp.power.Asleep = false
+	time.Sleep(time.Millisecond * 120) // datasheet: 120ms before CMD_SLPIN
+	return nil
+}
+
+// SetIdle turns idle mode on or off. In idle mode only the most significant
+// bit of each color channel is shown, 8 colors in total, saving power.
+func (disp *Ili948x) SetIdle(idle bool) error {
+	cmd := uint8(CMD_IDMOFF)
+	if idle {
+		cmd = CMD_IDMON
+	}
+	if err := disp.writeCmd(cmd); err != nil {
+		return err
+	}
+	disp.power.Idle = idle
+	return nil
+}
+
+// SetDisplayOn shows or blanks the frame memory on the panel. The frame
+// memory keeps its content and can still be drawn on while the display is off.
+func (disp *Ili948x) SetDisplayOn(on bool) error {
+	cmd := uint8(CMD_DISOFF)
+	if on {
+		cmd = CMD_DISON
+	}
+	if err := disp.writeCmd(cmd); err != nil {
+		return err
+	}
+	disp.power.DisplayOn = on
+	return nil
+}
@@ -0,0 +1,147 @@
+package ili948x_test
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"testing"
+
+	"github.com/inindev/ili948x"
+)
+
+func TestPowerSimulated(t *testing.T) {
+	disp, d := newSimulated(t)
+	if got, want := disp.GetPowerState(), (ili948x.PowerState{DisplayOn: true}); got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	// a color and its most significant bits, which idle mode shows alike
+	if err := disp.FillRectangle(0, 0, 1, 1, 0x60a0c0); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.FillRectangle(1, 0, 1, 1, 0x00fcfc); err != nil {
+		t.Fatal(err)
+	}
+	black := color.RGBA{A: 0xff}
+	lit := d.Image().RGBAAt(0, 0)
+
+	if err := disp.Sleep(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := disp.GetPowerState(), (ili948x.PowerState{Asleep: true, DisplayOn: true}); got != want {
+		t.Errorf("asleep: got %+v, want %+v", got, want)
+	}
+	if c := d.Image().RGBAAt(0, 0); c != black {
+		t.Errorf("asleep: panel shows %v", c)
+	}
+	for name, draw := range map[string]func() error{
+		"FillRectangle": func() error { return disp.FillRectangle(0, 0, 1, 1, 0xffffff) },
+		"FillScreen":    func() error { return disp.FillScreen(0xffffff) },
+		"DrawLine":      func() error { return disp.DrawLine(0, 0, 5, 3, 0xffffff) },
+		"FillCircle":    func() error { return disp.FillCircle(5, 5, 3, 0xffffff) },
+		"DrawText":      func() error { return disp.DrawText(0, 0, "a", ili948x.Font7x13, 0xffffff, 0) },
+		"DisplayBitmap": func() error {
+			return disp.DisplayBitmap(0, 0, 1, 1, 24, bytes.NewReader([]uint8{0xff, 0xff, 0xff}))
+		},
+	} {
+		if err := draw(); !errors.Is(err, ili948x.ErrAsleep) {
+			t.Errorf("asleep: %s got %v, want ErrAsleep", name, err)
+		}
+	}
+	if p := d.Pixel(0, 0); p != 0x30<<12|0x28<<6|0x18 {
+		t.Errorf("asleep: frame memory changed to %#05x", p)
+	}
+
+	if err := disp.Wake(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := disp.GetPowerState(), (ili948x.PowerState{DisplayOn: true}); got != want {
+		t.Errorf("awake: got %+v, want %+v", got, want)
+	}
+	if c := d.Image().RGBAAt(0, 0); c != lit {
+		t.Errorf("awake: panel shows %v, want %v", c, lit)
+	}
+	if err := disp.FillRectangle(2, 0, 1, 1, 0xfcfcfc); err != nil {
+		t.Errorf("awake: %v", err)
+	}
+	if p := d.Pixel(2, 0); p != 0x3ffff {
+		t.Errorf("awake: got pixel %#05x", p)
+	}
+
+	if err := disp.SetIdle(true); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := disp.GetPowerState(), (ili948x.PowerState{Idle: true, DisplayOn: true}); got != want {
+		t.Errorf("idle: got %+v, want %+v", got, want)
+	}
+	img := d.Image()
+	if img.RGBAAt(0, 0) != img.RGBAAt(1, 0) {
+		t.Errorf("idle: %v and %v differ", img.RGBAAt(0, 0), img.RGBAAt(1, 0))
+	}
+	if err := disp.SetIdle(false); err != nil {
+		t.Fatal(err)
+	}
+	if c := d.Image().RGBAAt(0, 0); c != lit || disp.GetPowerState().Idle {
+		t.Errorf("idle off: panel shows %v, state %+v", c, disp.GetPowerState())
+	}
+
+	// the frame memory can be drawn on while the display is off
+	if err := disp.SetDisplayOn(false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := disp.GetPowerState(), (ili948x.PowerState{}); got != want {
+		t.Errorf("off: got %+v, want %+v", got, want)
+	}
+	if err := disp.FillRectangle(0, 0, 1, 1, 0xfcfcfc); err != nil {
+		t.Errorf("off: %v", err)
+	}
+	if c := d.Image().RGBAAt(0, 0); c != black {
+		t.Errorf("off: panel shows %v", c)
+	}
+	if err := disp.SetDisplayOn(true); err != nil {
+		t.Fatal(err)
+	}
+	if c, want := d.Image().RGBAAt(0, 0), (color.RGBA{0xff, 0xff, 0xff, 0xff}); c != want {
+		t.Errorf("on: panel shows %v, want %v", c, want)
+	}
+}
+
+func TestPowerCommands(t *testing.T) {
+	disp, rec := newRecorded(t)
+	for _, tt := range []struct {
+		name string
+		call func() error
+		cmds []uint8
+	}{
+		{"Sleep", disp.Sleep, []uint8{ili948x.CMD_SLPIN}},
+		{"Sleep again", disp.Sleep, nil},
+		{"Wake", disp.Wake, []uint8{ili948x.CMD_SLPOUT}},
+		{"Wake again", disp.Wake, nil},
+		{"SetIdle", func() error { return disp.SetIdle(true) }, []uint8{ili948x.CMD_IDMON}},
+		{"SetIdle off", func() error { return disp.SetIdle(false) }, []uint8{ili948x.CMD_IDMOFF}},
+		{"SetDisplayOn off", func() error { return disp.SetDisplayOn(false) }, []uint8{ili948x.CMD_DISOFF}},
+		{"SetDisplayOn", func() error { return disp.SetDisplayOn(true) }, []uint8{ili948x.CMD_DISON}},
+	} {
+		rec.Reset()
+		if err := tt.call(); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.Commands(); !bytes.Equal(got, tt.cmds) {
+			t.Errorf("%s: sent %x, want %x", tt.name, got, tt.cmds)
+		}
+	}
+
+	// a failed command leaves the state as it was
+	rec.SetError(errors.New("bus"))
+	for name, call := range map[string]func() error{
+		"Sleep":        disp.Sleep,
+		"SetIdle":      func() error { return disp.SetIdle(true) },
+		"SetDisplayOn": func() error { return disp.SetDisplayOn(false) },
+	} {
+		if err := call(); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	if got, want := disp.GetPowerState(), (ili948x.PowerState{DisplayOn: true}); got != want {
+		t.Errorf("after errors: got %+v, want %+v", got, want)
+	}
+}
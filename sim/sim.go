@@ -0,0 +1,515 @@
+// Package sim provides a software model of an ILI9488 display controller.
+// It consumes the command and data stream produced by the ili948x driver,
+// maintains the 320x480 18-bit frame memory (GRAM) and renders what the panel
+// would show, so drawing, rotation and scrolling can be verified on a host.
+package sim
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/inindev/ili948x"
+)
+
+const (
+	Width  = 320 // gram columns
+	Height = 480 // gram rows
+)
+
+var _ ili948x.ReadTransport = (*Display)(nil)
+
+// params lists the number of parameter bytes of the commands the model applies.
+var params = map[uint8]int{
+	ili948x.CMD_CASET:    4,
+	ili948x.CMD_PASET:    4,
+	ili948x.CMD_MADCTRL:  1,
+	ili948x.CMD_PIXFMT:   1,
+	ili948x.CMD_VSCRDEF:  6,
+	ili948x.CMD_VSCRSADD: 2,
+	ili948x.CMD_PLTAR:    4,
+	ili948x.CMD_WRCABC:   1,
+	ili948x.CMD_WRCABCMB: 1,
+}
+
+// Display is a virtual ILI9488 controller with its panel. It implements
+// ili948x.Transport and interprets bytes according to its data / command pin,
+// in the same byte order the SPI transport uses on the wire.
+type Display struct {
+	ID  [3]uint8 // CMD_RDDIDIF response
+	ID4 [3]uint8 // CMD_RDID4 response
+
+	gram [Width * Height]uint32 // 6 bits per channel: r<<12 | g<<6 | b
+
+	dc     bool    // data / command pin level
+	cmd    uint8   // current command
+	params []uint8 // parameters received for the current command
+	pixel  []uint8 // partially received pixel bytes
+
+	madctl uint8 // CMD_MADCTRL
+	colmod uint8 // CMD_PIXFMT
+
+	sc, ec uint16 // column address window
+	sp, ep uint16 // page address window
+	x, y   uint16 // address counter within the window
+
+	dummy bool     // dummy byte pending on read
+	rd    [3]uint8 // pixel being read
+	rdPos int      // next byte of rd to read
+
+	tfa, vsa, bfa uint16 // vertical scrolling definition
+	ssa           uint16 // vertical scrolling start address
+	scrolling     bool   // vertical scroll mode
+
+	sr, er  uint16 // partial area
+	partial bool   // partial mode
+
+	cabc   uint8 // CMD_WRCABC
+	cabcMB uint8 // CMD_WRCABCMB
+
+	asleep   bool // sleep in
+	on       bool // display on
+	inverted bool // display inversion on
+	idle     bool // idle (8 color) mode on
+	allOff   bool // all pixels off
+	allOn    bool // all pixels on
+}
+
+// New returns an ILI9488 controller in its power-on reset state.
+func New() *Display {
+	d := &Display{
+		ID:  [3]uint8{0x54, 0x80, 0x66},
+		ID4: [3]uint8{0x00, 0x94, 0x88},
+		dc:  true,
+	}
+	d.reset()
+	return d
+}
+
+// DC returns the data / command pin to pass to the display constructor.
+func (d *Display) DC() ili948x.Pin {
+	return (*dcPin)(d)
+}
+
+// Pixel returns the 18-bit GRAM content at the given memory column and row
+// as r<<12 | g<<6 | b.
+func (d *Display) Pixel(col, row int) uint32 {
+	return d.gram[row*Width+col]
+}
+
+// MADCTRL returns the current memory access control register.
+func (d *Display) MADCTRL() uint8 {
+	return d.madctl
+}
+
+// Image renders the visible panel, taking scrolling, partial mode, inversion,
+// idle mode, display on/off and BGR order into account. The image is in panel
+// orientation: 320 pixels wide and 480 pixels high.
+func (d *Display) Image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	for row := 0; row < Height; row++ {
+		mrow := d.memoryRow(row)
+		for col := 0; col < Width; col++ {
+			c := d.panelColor(d.gram[mrow*Width+col])
+			if !d.shown(row) {
+				c = color.RGBA{A: 0xff}
+			}
+			img.SetRGBA(col, row, c)
+		}
+	}
+	return img
+}
+
+// WritePNG encodes the visible panel as a PNG image.
+func (d *Display) WritePNG(w io.Writer) error {
+	return png.Encode(w, d.Image())
+}
+
+// memoryRow returns the GRAM row shown on the given panel row.
+func (d *Display) memoryRow(row int) int {
+	if !d.scrolling || d.vsa == 0 {
+		return row
+	}
+	// with ML set the scrolling definition counts from the bottom of the panel
+	ml := d.madctl&ili948x.MADCTRL_ML != 0
+	if ml {
+		row = Height - 1 - row
+	}
+	tfa, vsa := int(d.tfa), int(d.vsa)
+	mrow := row
+	if row >= tfa && row < tfa+vsa {
+		mrow = tfa + ((row-tfa+int(d.ssa)-tfa)%vsa+vsa)%vsa
+	}
+	if ml {
+		mrow = Height - 1 - mrow
+	}
+	return mrow
+}
+
+// shown reports whether a panel row is lit, rows outside the partial area
+// are blanked in partial mode.
+func (d *Display) shown(row int) bool {
+	if !d.partial {
+		return true
+	}
+	// like the scrolling definition, the partial area counts from the bottom
+	// of the panel with ML set
+	if d.madctl&ili948x.MADCTRL_ML != 0 {
+		row = Height - 1 - row
+	}
+	sr, er := int(d.sr), int(d.er)
+	if sr <= er {
+		return row >= sr && row <= er
+	}
+	return row >= sr || row <= er
+}
+
+// panelColor converts an 18-bit GRAM value to the color lit on the panel.
+func (d *Display) panelColor(v uint32) color.RGBA {
+	if d.asleep || !d.on || d.allOff {
+		return color.RGBA{A: 0xff}
+	}
+	if d.allOn {
+		return color.RGBA{0xff, 0xff, 0xff, 0xff}
+	}
+	r, g, b := uint8(v>>12)&0x3f, uint8(v>>6)&0x3f, uint8(v)&0x3f
+	// the panel is wired blue-green-red, so with BGR clear the first channel
+	// of a pixel, the low byte of a driver color, lights the blue subpixel
+	if d.madctl&ili948x.MADCTRL_BGR == 0 {
+		r, b = b, r
+	}
+	if d.inverted {
+		r, g, b = r^0x3f, g^0x3f, b^0x3f
+	}
+	if d.idle {
+		r, g, b = msb6(r), msb6(g), msb6(b)
+	}
+	return color.RGBA{expand6(r), expand6(g), expand6(b), 0xff}
+}
+
+// reset returns the controller to its reset defaults; GRAM is preserved.
+func (d *Display) reset() {
+	d.cmd = ili948x.CMD_NOP
+	d.params = d.params[:0]
+	d.pixel = d.pixel[:0]
+	d.madctl = 0
+	d.colmod = 0x66
+	d.sc, d.ec = 0, Width-1
+	d.sp, d.ep = 0, Height-1
+	d.tfa, d.vsa, d.bfa = 0, Height, 0
+	d.ssa = 0
+	d.scrolling = false
+	d.sr, d.er = 0, Height-1
+	d.partial = false
+	d.cabc, d.cabcMB = 0, 0
+	d.asleep = true
+	d.on = false
+	d.inverted = false
+	d.idle = false
+	d.allOff = false
+	d.allOn = false
+}
+
+// command starts a new command.
+func (d *Display) command(cmd uint8) {
+	d.cmd = cmd
+	d.params = d.params[:0]
+	d.pixel = d.pixel[:0]
+
+	switch cmd {
+	case ili948x.CMD_SWRESET:
+		d.reset()
+	case ili948x.CMD_SLPIN:
+		d.asleep = true
+	case ili948x.CMD_SLPOUT:
+		d.asleep = false
+	case ili948x.CMD_PTLON:
+		d.partial = true
+	case ili948x.CMD_NORON:
+		d.scrolling = false
+		d.partial = false
+	case ili948x.CMD_INVOFF:
+		d.inverted = false
+	case ili948x.CMD_INVON:
+		d.inverted = true
+	case ili948x.CMD_ALLPOFF:
+		d.allOff, d.allOn = true, false
+	case ili948x.CMD_ALLPON:
+		d.allOff, d.allOn = false, true
+	case ili948x.CMD_DISOFF:
+		d.on = false
+	case ili948x.CMD_DISON:
+		d.on = true
+		d.allOff, d.allOn = false, false
+	case ili948x.CMD_IDMOFF:
+		d.idle = false
+	case ili948x.CMD_IDMON:
+		d.idle = true
+	case ili948x.CMD_RAMWR:
+		d.x, d.y = d.sc, d.sp
+	case ili948x.CMD_RAMRD:
+		d.x, d.y = d.sc, d.sp
+		fallthrough
+	case ili948x.CMD_RAMRDRC:
+		d.rdPos = len(d.rd)
+	case ili948x.CMD_RDDIDIF, ili948x.CMD_RDID4, ili948x.CMD_RDCABC, ili948x.CMD_RDCABCMB:
+		d.rdPos = 0
+	}
+	d.dummy = true
+}
+
+// read returns the next byte of the response to the current command.
+func (d *Display) read() uint8 {
+	if d.dummy {
+		d.dummy = false
+		return 0
+	}
+
+	switch d.cmd {
+	case ili948x.CMD_RAMRD, ili948x.CMD_RAMRDRC:
+		if d.rdPos == len(d.rd) {
+			var v uint32
+			if i, ok := d.address(); ok {
+				v = d.gram[i]
+			}
+			d.advance()
+			d.rd = [3]uint8{uint8(v>>12) << 2, uint8(v>>6) << 2, uint8(v) << 2}
+			d.rdPos = 0
+		}
+		b := d.rd[d.rdPos]
+		d.rdPos++
+		return b
+	case ili948x.CMD_RDDIDIF, ili948x.CMD_RDID4:
+		id := d.ID
+		if d.cmd == ili948x.CMD_RDID4 {
+			id = d.ID4
+		}
+		if d.rdPos < len(id) {
+			d.rdPos++
+			return id[d.rdPos-1]
+		}
+	case ili948x.CMD_RDCABC, ili948x.CMD_RDCABCMB:
+		if d.rdPos == 0 {
+			d.rdPos++
+			if d.cmd == ili948x.CMD_RDCABC {
+				return d.cabc
+			}
+			return d.cabcMB
+		}
+	}
+	return 0
+}
+
+// data handles a parameter or pixel byte for the current command.
+func (d *Display) data(b uint8) {
+	switch d.cmd {
+	case ili948x.CMD_RAMWR, ili948x.CMD_RAMWRC:
+		d.pixelData(b)
+		return
+	}
+
+	n, ok := params[d.cmd]
+	if !ok || len(d.params) >= n {
+		return
+	}
+	d.params = append(d.params, b)
+	if len(d.params) < n {
+		return
+	}
+
+	p := d.params
+	switch d.cmd {
+	case ili948x.CMD_CASET:
+		d.sc, d.ec = be16(p[0:]), be16(p[2:])
+	case ili948x.CMD_PASET:
+		d.sp, d.ep = be16(p[0:]), be16(p[2:])
+	case ili948x.CMD_MADCTRL:
+		d.madctl = p[0]
+	case ili948x.CMD_PIXFMT:
+		d.colmod = p[0]
+	case ili948x.CMD_VSCRDEF:
+		d.tfa, d.vsa, d.bfa = be16(p[0:]), be16(p[2:]), be16(p[4:])
+	case ili948x.CMD_VSCRSADD:
+		d.ssa = be16(p[0:])
+		d.scrolling = true
+	case ili948x.CMD_PLTAR:
+		d.sr, d.er = be16(p[0:]), be16(p[2:])
+	case ili948x.CMD_WRCABC:
+		d.cabc = p[0] & 0x03
+	case ili948x.CMD_WRCABCMB:
+		d.cabcMB = p[0]
+	}
+}
+
+// pixelData collects pixel bytes in the current interface pixel format.
+func (d *Display) pixelData(b uint8) {
+	d.pixel = append(d.pixel, b)
+	switch d.colmod & 0x07 {
+	case 0x05: // 16 bits / pixel, rgb 565
+		if len(d.pixel) < 2 {
+			return
+		}
+		v := uint16(d.pixel[0])<<8 | uint16(d.pixel[1])
+		r, g, bl := uint32(v>>11)&0x1f, uint32(v>>5)&0x3f, uint32(v)&0x1f
+		d.store((r<<1|r>>4)<<12 | g<<6 | (bl<<1 | bl>>4))
+	default: // 18 bits / pixel, one byte per channel
+		if len(d.pixel) < 3 {
+			return
+		}
+		r, g, bl := uint32(d.pixel[0]>>2), uint32(d.pixel[1]>>2), uint32(d.pixel[2]>>2)
+		d.store(r<<12 | g<<6 | bl)
+	}
+	d.pixel = d.pixel[:0]
+}
+
+// store writes a pixel at the address counter and advances it.
+func (d *Display) store(v uint32) {
+	if i, ok := d.address(); ok {
+		d.gram[i] = v
+	}
+	d.advance()
+}
+
+// address maps the address counter to a GRAM index according to MADCTRL
+// MV, MX and MY, returning false if it is outside the frame memory.
+func (d *Display) address() (int, bool) {
+	col, row := int(d.x), int(d.y)
+	if d.madctl&ili948x.MADCTRL_MV != 0 {
+		col, row = row, col
+	}
+	if col >= Width || row >= Height {
+		return 0, false
+	}
+	if d.madctl&ili948x.MADCTRL_MX != 0 {
+		col = Width - 1 - col
+	}
+	if d.madctl&ili948x.MADCTRL_MY != 0 {
+		row = Height - 1 - row
+	}
+	return row*Width + col, true
+}
+
+// advance moves the address counter to the next pixel of the window.
+func (d *Display) advance() {
+	if d.x < d.ec {
+		d.x++
+		return
+	}
+	d.x = d.sc
+	if d.y < d.ep {
+		d.y++
+		return
+	}
+	d.y = d.sp
+}
+
+func (d *Display) write(data ...uint8) {
+	for _, b := range data {
+		if d.dc {
+			d.data(b)
+		} else {
+			d.command(b)
+		}
+	}
+}
+
+// 8 bit
+func (d *Display) Write8(data uint8) error {
+	d.write(data)
+	return nil
+}
+
+func (d *Display) Write8n(data uint8, n int) error {
+	for i := 0; i < n; i++ {
+		d.write(data)
+	}
+	return nil
+}
+
+func (d *Display) Write8sl(data []uint8) error {
+	d.write(data...)
+	return nil
+}
+
+// 16 bit
+func (d *Display) Write16(data uint16) error {
+	d.write(uint8(data), uint8(data>>8))
+	return nil
+}
+
+func (d *Display) Write16n(data uint16, n int) error {
+	for i := 0; i < n; i++ {
+		d.Write16(data)
+	}
+	return nil
+}
+
+func (d *Display) Write16sl(data []uint16) error {
+	for _, v := range data {
+		d.Write16(v)
+	}
+	return nil
+}
+
+// 24 bit
+func (d *Display) Write24(data uint32) error {
+	d.write(uint8(data), uint8(data>>8), uint8(data>>16))
+	return nil
+}
+
+func (d *Display) Write24n(data uint32, n int) error {
+	for i := 0; i < n; i++ {
+		d.Write24(data)
+	}
+	return nil
+}
+
+func (d *Display) Write24sl(data []uint32) error {
+	for _, v := range data {
+		d.Write24(v)
+	}
+	return nil
+}
+
+func (d *Display) Read8sl(data []uint8) error {
+	for i := range data {
+		data[i] = d.read()
+	}
+	return nil
+}
+
+func (d *Display) SetReadMode(read bool) error {
+	return nil
+}
+
+// dcPin tracks the data / command pin level for its Display.
+type dcPin Display
+
+func (p *dcPin) Set(high bool) {
+	p.dc = high
+}
+
+func (p *dcPin) High() {
+	p.dc = true
+}
+
+func (p *dcPin) Low() {
+	p.dc = false
+}
+
+func be16(b []uint8) uint16 {
+	return uint16(b[0])<<8 | uint16(b[1])
+}
+
+func expand6(v uint8) uint8 {
+	return v<<2 | v>>4
+}
+
+// msb6 saturates a 6-bit channel to its most significant bit, as in idle mode.
+func msb6(v uint8) uint8 {
+	if v&0x20 != 0 {
+		return 0x3f
+	}
+	return 0
+}
@@ -0,0 +1,232 @@
+package sim_test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/sim"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+// newDisplay returns a driver attached to a simulated controller.
+func newDisplay(t *testing.T) (*ili948x.Ili948x, *sim.Display) {
+	t.Helper()
+	d := sim.New()
+	disp, err := ili948x.New(d, nil, d.DC(), nil, nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return disp, d
+}
+
+// bitmap returns a w by h gradient in the DisplayBitmap stream format.
+func bitmap(w, h int, bpp uint8) []uint8 {
+	var buf []uint8
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := uint32(x*255/(w-1))<<16 | uint32(y*255/(h-1))<<8 | 0x40
+			if bpp == 16 {
+				v := rgb565(c)
+				buf = append(buf, uint8(v), uint8(v>>8))
+			} else {
+				buf = append(buf, uint8(c), uint8(c>>8), uint8(c>>16))
+			}
+		}
+	}
+	return buf
+}
+
+// rgb565 converts a 0xrrggbb color to rgb565.
+func rgb565(c uint32) uint16 {
+	return uint16(c>>8)&0xf800 | uint16(c>>5)&0x07e0 | uint16(c>>3)&0x1f
+}
+
+// drawScene draws an asymmetric test scene: a marker in three corners and
+// the same gradient as a 24 and a 16 bpp bitmap.
+func drawScene(t *testing.T, disp *ili948x.Ili948x) {
+	t.Helper()
+	w, h := disp.Size()
+	steps := []error{
+		disp.FillScreen(0x000000),
+		disp.FillRectangle(0, 0, 40, 20, 0xff0000),
+		disp.FillRectangle(w-20, 0, 20, 40, 0x00ff00),
+		disp.FillRectangle(0, h-10, 10, 10, 0x0000ff),
+		disp.DrawLine(0, 0, w-1, h-1, 0xffffff),
+		disp.DisplayBitmap(60, 40, 32, 16, 24, bytes.NewReader(bitmap(32, 16, 24))),
+		disp.DisplayBitmap(60, 60, 32, 16, 16, bytes.NewReader(bitmap(32, 16, 16))),
+		// clipped at the bottom right
+		disp.DisplayBitmap(w-16, h-8, 32, 16, 24, bytes.NewReader(bitmap(32, 16, 24))),
+	}
+	for i, err := range steps {
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+}
+
+// orientation maps logical coordinates of a display sized w by h to the
+// panel pixel they are shown on.
+type orientation struct {
+	rot    ili948x.Rotation
+	mirror bool
+	panel  func(x, y int) (int, int)
+}
+
+const lastCol, lastRow = sim.Width - 1, sim.Height - 1
+
+var orientations = []orientation{
+	{ili948x.Rot_0, false, func(x, y int) (int, int) { return x, y }},
+	{ili948x.Rot_90, false, func(x, y int) (int, int) { return lastCol - y, x }},
+	{ili948x.Rot_180, false, func(x, y int) (int, int) { return lastCol - x, lastRow - y }},
+	{ili948x.Rot_270, false, func(x, y int) (int, int) { return y, lastRow - x }},
+	{ili948x.Rot_0, true, func(x, y int) (int, int) { return lastCol - x, y }},
+	{ili948x.Rot_90, true, func(x, y int) (int, int) { return lastCol - y, lastRow - x }},
+	{ili948x.Rot_180, true, func(x, y int) (int, int) { return x, lastRow - y }},
+	{ili948x.Rot_270, true, func(x, y int) (int, int) { return y, x }},
+}
+
+func (o orientation) String() string {
+	s := fmt.Sprintf("rot%d", 90*int(o.rot))
+	if o.mirror {
+		s += "_mirror"
+	}
+	return s
+}
+
+func TestOrientationGolden(t *testing.T) {
+	for _, o := range orientations {
+		o := o
+		t.Run(o.String(), func(t *testing.T) {
+			disp, d := newDisplay(t)
+			if err := disp.SetRotation(o.rot); err != nil {
+				t.Fatal(err)
+			}
+			if err := disp.SetMirror(o.mirror); err != nil {
+				t.Fatal(err)
+			}
+			drawScene(t, disp)
+			checkGolden(t, d, o.String()+".png")
+		})
+	}
+}
+
+// TestOrientationMapping checks every orientation against the unrotated
+// scene, independently of the golden images.
+func TestOrientationMapping(t *testing.T) {
+	var ref []uint32
+	for _, o := range orientations {
+		disp, d := newDisplay(t)
+		if err := disp.SetRotation(o.rot); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.SetMirror(o.mirror); err != nil {
+			t.Fatal(err)
+		}
+		drawScene(t, disp)
+
+		w, h := disp.Size()
+		if (o.rot == ili948x.Rot_90 || o.rot == ili948x.Rot_270) != (w > h) {
+			t.Fatalf("%v: size %dx%d", o, w, h)
+		}
+		// the scene is laid out relative to the display size, so compare
+		// the same scene features in each orientation
+		for i, p := range []image.Point{
+			{0, 0}, {39, 19}, // red
+			{int(w) - 1, 0}, {int(w) - 20, 39}, // green
+			{0, int(h) - 1}, {9, int(h) - 10}, // blue
+			{60, 40}, {91, 55}, {60, 60}, {91, 75}, // bitmaps
+			{int(w) - 1, int(h) - 1}, {int(w) - 16, int(h) - 8}, // clipped bitmap
+			{20, 2}, {65, 50}, // background
+		} {
+			col, row := o.panel(p.X, p.Y)
+			got := d.Pixel(col, row)
+			if o.rot == ili948x.Rot_0 && !o.mirror {
+				ref = append(ref, got)
+			} else if want := ref[i]; got != want {
+				t.Errorf("%v: logical %v on panel (%d, %d) is %05x, want %05x", o, p, col, row, got, want)
+			}
+		}
+	}
+}
+
+func TestBitmapPixels(t *testing.T) {
+	disp, d := newDisplay(t)
+	drawScene(t, disp)
+
+	// fill returns the frame memory content of a pixel filled with c
+	fill := func(c uint32) uint32 {
+		if err := disp.FillRectangle(200, 200, 1, 1, c); err != nil {
+			t.Fatal(err)
+		}
+		return d.Pixel(200, 200)
+	}
+	for _, p := range []image.Point{{0, 0}, {31, 0}, {0, 15}, {31, 15}, {10, 7}} {
+		c := uint32(p.X*255/31)<<16 | uint32(p.Y*255/15)<<8 | 0x40
+		if got, want := d.Pixel(60+p.X, 40+p.Y), fill(c); got != want {
+			t.Errorf("24 bpp %v: got %05x, want %05x", p, got, want)
+		}
+		c = ili948x.RGB565ToColor(rgb565(c))
+		if got, want := d.Pixel(60+p.X, 60+p.Y), fill(c); got != want {
+			t.Errorf("16 bpp %v: got %05x, want %05x", p, got, want)
+		}
+	}
+	// the clipped bitmap shows its top left 16x8 pixels
+	if got, want := d.Pixel(sim.Width-1, sim.Height-1), fill(uint32(15*255/31)<<16|uint32(7*255/15)<<8|0x40); got != want {
+		t.Errorf("clipped: got %05x, want %05x", got, want)
+	}
+}
+
+// checkGolden compares the rendered panel with testdata/name, rewriting it
+// with -update.
+func checkGolden(t *testing.T, d *sim.Display, name string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		var buf bytes.Buffer
+		if err := d.WritePNG(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	golden, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := d.Image()
+	if golden.Bounds() != got.Bounds() {
+		t.Fatalf("bounds %v, golden %v", got.Bounds(), golden.Bounds())
+	}
+	diffs := 0
+	for y := 0; y < sim.Height; y++ {
+		for x := 0; x < sim.Width; x++ {
+			gr, gg, gb, _ := golden.At(x, y).RGBA()
+			c := got.RGBAAt(x, y)
+			if uint8(gr>>8) != c.R || uint8(gg>>8) != c.G || uint8(gb>>8) != c.B {
+				if diffs < 5 {
+					t.Errorf("pixel (%d, %d): got %v, golden %02x%02x%02x", x, y, c, gr>>8, gg>>8, gb>>8)
+				}
+				diffs++
+			}
+		}
+	}
+	if diffs > 0 {
+		t.Errorf("%d pixels differ from %s", diffs, path)
+	}
+}
@@ -1,67 +1,99 @@
-package main
+//go:build tinygo
+
+package ili948x
 
 import (
 	"machine"
 )
 
 type spiTransport struct {
-	spi machine.SPI // spi bus
-	buf []uint8     // spi data buffer
+	spi      machine.SPI        // spi bus
+	buf      []uint8            // spi data buffer
+	config   *machine.SPIConfig // bus configuration for writes
+	readFreq uint32             // bus frequency for reads
 }
 
-func NewSPITransport(spi machine.SPI) iTransport {
+// NewSPITransport returns a Transport that writes to the given SPI bus.
+func NewSPITransport(spi machine.SPI) Transport {
 	return &spiTransport{
 		spi: spi,
 		buf: make([]uint8, 64),
 	}
 }
 
+// NewSPIReadTransport returns a Transport that can also read from the display.
+// The ILI9488 serial read clock is much slower than its write clock, so the bus
+// is reconfigured with readFrequency for reads and restored to config after.
+func NewSPIReadTransport(spi machine.SPI, config machine.SPIConfig, readFrequency uint32) ReadTransport {
+	return &spiTransport{
+		spi:      spi,
+		buf:      make([]uint8, 64),
+		config:   &config,
+		readFreq: readFrequency,
+	}
+}
+
+func (st *spiTransport) Read8sl(data []uint8) error {
+	return st.spi.Tx(nil, data)
+}
+
+func (st *spiTransport) SetReadMode(read bool) error {
+	if st.config == nil {
+		return nil
+	}
+	config := *st.config
+	if read {
+		config.Frequency = st.readFreq
+	}
+	return st.spi.Configure(config)
+}
+
 // 8 bit
-func (st *spiTransport) write8(data uint8) {
+func (st *spiTransport) Write8(data uint8) error {
 	st.buf[0] = data
-	st.spi.Tx(st.buf[:1], nil)
+	return st.spi.Tx(st.buf[:1], nil)
 }
 
-func (st *spiTransport) write8n(data uint8, n int) {
-	writeNn[uint8](st, data, n, 1)
+func (st *spiTransport) Write8n(data uint8, n int) error {
+	return writeNn[uint8](st, data, n, 1)
 }
 
-func (st *spiTransport) write8sl(data []uint8) {
-	writeNsl[uint8](st, data, 1)
+func (st *spiTransport) Write8sl(data []uint8) error {
+	return writeNsl[uint8](st, data, 1)
 }
 
 // 16 bit
-func (st *spiTransport) write16(data uint16) {
+func (st *spiTransport) Write16(data uint16) error {
 	st.buf[0] = uint8(data)
 	st.buf[1] = uint8(data >> 8)
-	st.spi.Tx(st.buf[:2], nil)
+	return st.spi.Tx(st.buf[:2], nil)
 }
 
-func (st *spiTransport) write16n(data uint16, n int) {
-	writeNn[uint16](st, data, n, 2)
+func (st *spiTransport) Write16n(data uint16, n int) error {
+	return writeNn[uint16](st, data, n, 2)
 }
 
-func (st *spiTransport) write16sl(data []uint16) {
-	writeNsl[uint16](st, data, 2)
+func (st *spiTransport) Write16sl(data []uint16) error {
+	return writeNsl[uint16](st, data, 2)
 }
 
 // 24 bit
-func (st *spiTransport) write24(data uint32) {
+func (st *spiTransport) Write24(data uint32) error {
 	st.buf[0] = uint8(data)
 	st.buf[1] = uint8(data >> 8)
 	st.buf[2] = uint8(data >> 16)
-	st.spi.Tx(st.buf[:3], nil)
+	return st.spi.Tx(st.buf[:3], nil)
 }
 
-func (st *spiTransport) write24n(data uint32, n int) {
-	writeNn[uint32](st, data, n, 3)
+func (st *spiTransport) Write24n(data uint32, n int) error {
+	return writeNn[uint32](st, data, n, 3)
 }
 
-func (st *spiTransport) write24sl(data []uint32) {
-	writeNsl[uint32](st, data, 3)
+func (st *spiTransport) Write24sl(data []uint32) error {
+	return writeNsl[uint32](st, data, 3)
 }
 
-func writeNn[T int8 | uint8 | int16 | uint16 | int32 | uint32](st *spiTransport, data T, n, bytes int) {
+func writeNn[T int8 | uint8 | int16 | uint16 | int32 | uint32](st *spiTransport, data T, n, bytes int) error {
 	dataBytes := n * bytes
 	bufBytes := (len(st.buf) / bytes) * bytes
 
@@ -72,13 +104,16 @@ func writeNn[T int8 | uint8 | int16 | uint16 | int32 | uint32](st *spiTransport,
 			pos++
 		}
 		if pos >= bufBytes || pos >= dataBytes {
-			st.spi.Tx(st.buf[:pos], nil) // transmit
+			if err := st.spi.Tx(st.buf[:pos], nil); err != nil { // transmit
+				return err
+			}
 			dataBytes -= pos
 		}
 	}
+	return nil
 }
 
-func writeNsl[T int8 | uint8 | int16 | uint16 | int32 | uint32](st *spiTransport, data []T, bytes int) {
+func writeNsl[T int8 | uint8 | int16 | uint16 | int32 | uint32](st *spiTransport, data []T, bytes int) error {
 	dataBytes := len(data) * bytes
 	bufBytes := (len(st.buf) / bytes) * bytes
 
@@ -89,8 +124,11 @@ func writeNsl[T int8 | uint8 | int16 | uint16 | int32 | uint32](st *spiTransport
 			pos++
 		}
 		if pos >= bufBytes || pos >= dataBytes {
-			st.spi.Tx(st.buf[:pos], nil) // transmit
+			if err := st.spi.Tx(st.buf[:pos], nil); err != nil { // transmit
+				return err
+			}
 			dataBytes -= pos
 		}
 	}
+	return nil
 }
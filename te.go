@@ -0,0 +1,111 @@
+package ili948x
+
+import (
+	"runtime"
+	"time"
+)
+
+const (
+	framePeriod      = time.Second / 60      // panel refresh period at the default frame rate
+	defaultTETimeout = time.Millisecond * 50 // longest wait for a TE pulse, about three frames
+)
+
+// WithTE connects the controller's tearing effect output, which is then
+// enabled at init. WaitForVSync and BeginFrame sync to it.
+func WithTE(te InputPin) Option {
+	return func(disp *Ili948x) {
+		disp.te = te
+	}
+}
+
+// WithTETimeout sets how long WaitForVSync and BeginFrame wait for a TE pulse
+// before failing with ErrTimeout, 50ms by default. Lower frame rates set with
+// CMD_FRMCTRL1 need a longer timeout.
+func WithTETimeout(timeout time.Duration) Option {
+	return func(disp *Ili948x) {
+		disp.teTimeout = timeout
+	}
+}
+
+// SetTearingEffect turns the TE output on or off. When on, TE goes high as
+// the panel refresh reaches the tear scan line, line 0 by default.
+func (disp *Ili948x) SetTearingEffect(on bool) error {
+	var err error
+	if on {
+		err = disp.writeCmd(CMD_TEON,
+			0x00, // M: v-blanking information only
+		)
+	} else {
+		err = disp.writeCmd(CMD_TEOFF)
+	}
+	if err != nil {
+		return err
+	}
+	disp.teOn = on
+	return nil
+}
+
+// WaitForVSync blocks until the panel refresh starts a new frame. Without a TE
+// pin it sleeps for one frame period. An error wrapping ErrTimeout is returned
+// when no TE pulse arrives within the WithTETimeout timeout, and right away
+// when the TE output is off.
+func (disp *Ili948x) WaitForVSync() error {
+	return disp.waitTE("WaitForVSync", 0)
+}
+
+// BeginFrame blocks until the panel refresh has passed the lines from
+// startLine to endLine inclusive, so they can be redrawn before the refresh
+// returns to them. Like SetPartialArea, lines are rows in Rot_0 and Rot_180
+// and columns in Rot_90 and Rot_270, counted from the top or left also with
+// the scroll direction reversed. Without a TE pin it sleeps for one frame
+// period. Like WaitForVSync it fails with ErrTimeout when no TE pulse arrives.
+func (disp *Ili948x) BeginFrame(startLine, endLine uint16) error {
+	if startLine > endLine || endLine >= disp.height {
+		return &Error{Op: "BeginFrame", Kind: ErrOutOfBounds}
+	}
+	// the refresh passes the lines from the last one in its scan order,
+	// the first one with the scroll direction reversed
+	last := endLine
+	if disp.scrollReverse {
+		last = disp.gateLine(startLine)
+	}
+	line := last + 1
+	if line >= disp.height {
+		line = 0
+	}
+	return disp.waitTE("BeginFrame", line)
+}
+
+// waitTE waits for the rising edge of the TE line at the given controller
+// scan line.
+func (disp *Ili948x) waitTE(op string, line uint16) error {
+	if disp.te == nil {
+		time.Sleep(framePeriod)
+		return nil
+	}
+	if !disp.teOn {
+		return &Error{Op: op, Kind: ErrTimeout}
+	}
+
+	if line != disp.teLine {
+		if err := disp.writeCmd(CMD_TESLWR,
+			uint8(line>>8),
+			uint8(line),
+		); err != nil {
+			return err
+		}
+		disp.teLine = line
+	}
+
+	// skip a pulse in progress, then wait for the next one
+	deadline := time.Now().Add(disp.teTimeout)
+	for _, level := range [2]bool{false, true} {
+		for disp.te.Get() != level {
+			if time.Now().After(deadline) {
+				return &Error{Op: op, Kind: ErrTimeout}
+			}
+			runtime.Gosched() // let other goroutines run while polling
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package ili948x_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/trace"
+)
+
+// tePin is a TE output pulsing high for the first millisecond of every period,
+// or stuck at level when period is 0.
+type tePin struct {
+	start  time.Time
+	period time.Duration
+	level  bool
+}
+
+func (p *tePin) Get() bool {
+	if p.period == 0 {
+		return p.level
+	}
+	return time.Since(p.start)%p.period < time.Millisecond
+}
+
+func newTEDisplay(t *testing.T, te *tePin, opts ...ili948x.Option) (*ili948x.Ili948x, *trace.Recorder) {
+	t.Helper()
+	rec := trace.NewRecorder()
+	opts = append(opts, ili948x.WithTE(te))
+	disp, err := ili948x.NewIli9488(rec, nil, rec.DC(), nil, nil, 0, 0, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Reset()
+	return disp, rec
+}
+
+func TestWaitForVSync(t *testing.T) {
+	te := &tePin{start: time.Now(), period: 5 * time.Millisecond}
+	disp, rec := newTEDisplay(t, te)
+	for i := 0; i < 3; i++ {
+		if err := disp.WaitForVSync(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(rec.Ops()) != 0 {
+		t.Errorf("unexpected commands:\n%s", rec)
+	}
+
+	// the tear scan line is only written when it changes
+	for i := 0; i < 2; i++ {
+		if err := disp.BeginFrame(0, 99); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkOps(t, rec, []trace.Op{{Cmd: ili948x.CMD_TESLWR, Params: []uint8{0x00, 100}}})
+}
+
+func TestWaitForVSyncTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		level   bool
+		timeout time.Duration
+	}{
+		{"stuck low", false, 0},
+		{"stuck high", true, 0},
+		{"custom timeout", false, 5 * time.Millisecond},
+		{"long timeout", true, 150 * time.Millisecond},
+	} {
+		var opts []ili948x.Option
+		want := 50 * time.Millisecond
+		if tt.timeout > 0 {
+			opts = append(opts, ili948x.WithTETimeout(tt.timeout))
+			want = tt.timeout
+		}
+		disp, _ := newTEDisplay(t, &tePin{level: tt.level}, opts...)
+
+		start := time.Now()
+		err := disp.WaitForVSync()
+		elapsed := time.Since(start)
+		if !errors.Is(err, ili948x.ErrTimeout) {
+			t.Errorf("%s: got %v, want ErrTimeout", tt.name, err)
+		}
+		if elapsed < want || elapsed > want+time.Second {
+			t.Errorf("%s: timed out after %v, want %v", tt.name, elapsed, want)
+		}
+		if err := disp.BeginFrame(10, 20); !errors.Is(err, ili948x.ErrTimeout) {
+			t.Errorf("%s: BeginFrame got %v, want ErrTimeout", tt.name, err)
+		}
+	}
+}
+
+func TestWaitForVSyncTEOff(t *testing.T) {
+	te := &tePin{start: time.Now(), period: 5 * time.Millisecond}
+	disp, _ := newTEDisplay(t, te)
+	if err := disp.SetTearingEffect(false); err != nil {
+		t.Fatal(err)
+	}
+
+	// no pulses to wait for
+	start := time.Now()
+	if err := disp.WaitForVSync(); !errors.Is(err, ili948x.ErrTimeout) {
+		t.Errorf("got %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("waited %v with the TE output off", elapsed)
+	}
+
+	if err := disp.SetTearingEffect(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.WaitForVSync(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBeginFrameReversed(t *testing.T) {
+	te := &tePin{start: time.Now(), period: 5 * time.Millisecond}
+	disp, rec := newTEDisplay(t, te)
+	if err := disp.SetScrollReverse(true); err != nil {
+		t.Fatal(err)
+	}
+
+	// the refresh runs from the bottom up, past lines 100-199 at line 99,
+	// which is controller line 380
+	for _, tt := range []struct {
+		start, end uint16
+		want       uint16
+	}{
+		{100, 199, 380},
+		{0, 99, 0},
+		{400, 479, 80},
+	} {
+		rec.Reset()
+		if err := disp.BeginFrame(tt.start, tt.end); err != nil {
+			t.Fatal(err)
+		}
+		checkOps(t, rec, []trace.Op{{Cmd: ili948x.CMD_TESLWR, Params: []uint8{uint8(tt.want >> 8), uint8(tt.want)}}})
+	}
+}
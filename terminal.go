@@ -0,0 +1,850 @@
+package ili948x
+
+import (
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// terminal attribute flags
+const (
+	termBold uint8 = 1 << iota
+	termUnderline
+	termInverse
+)
+
+// termAttr holds the colors and flags of a cell.
+type termAttr struct {
+	fg, bg uint32
+	flags  uint8
+}
+
+// termCell is a character cell of the screen buffer.
+type termCell struct {
+	r rune
+	a termAttr
+}
+
+// termCursor is the cursor state saved by DECSC and CSI s.
+type termCursor struct {
+	col, row int
+	attr     termAttr
+	fgIndex  int
+}
+
+// terminal parser states
+const (
+	termGround uint8 = iota
+	termEscape
+	termEscapeSkip // ESC followed by an intermediate byte, skip the final byte
+	termCSI
+	termOSC
+	termOSCEscape
+)
+
+const termMaxParams = 16
+
+// Terminal is an ANSI / VT100 terminal emulator drawing on the display with a
+// monospace font, e.g. to show a serial shell. It implements io.Writer and
+// can be fed from any io.Reader with Run.
+//
+// Supported are the C0 controls BS, HT, LF, VT, FF and CR, the escape
+// sequences IND, NEL, RI, DECSC, DECRC and RIS, and the control sequences
+// CUU, CUD, CUF, CUB, CNL, CPL, CHA, CUP, HVP, VPA, ED, EL, ECH, ICH, DCH,
+// IL, DL, SU, SD, DECSTBM, SGR, the save and restore cursor sequences, and
+// the modes LNM, DECAWM and DECTCEM. SGR covers bold, underline and inverse
+// with the 16 and 256 color palettes and 24 bit colors. Other sequences are
+// parsed and ignored.
+//
+// The scroll region is mapped onto the hardware scroll area, rows outside of
+// it becoming the fixed areas. As the hardware scrolls along the panel's gate
+// lines, which are rows only in Rot_0 and Rot_180, the region is scrolled by
+// redrawing its rows in Rot_90 and Rot_270, which is much slower.
+type Terminal struct {
+	disp  *Ili948x
+	font  *Font
+	cw    int16 // cell width
+	ch    int16 // cell height
+	cols  int
+	rows  int
+	cells []termCell
+	cell  []uint32 // cell pixels
+
+	col, row int  // cursor
+	wrapNext bool // cursor is past the last column
+	attr     termAttr
+	fgIndex  int // palette index of the foreground color, -1 for none
+	saved    termCursor
+
+	top, bottom int    // scroll region rows, inclusive
+	first       uint16 // scroll area line of the top region row
+	softScroll  bool   // scroll by redrawing rows, without the hardware
+
+	autowrap bool // DECAWM
+	newline  bool // LNM: LF, VT and FF also return the carriage
+	cursorOn bool // DECTCEM
+	shown    bool // cursor drawn
+
+	state    uint8
+	private  uint8 // CSI private marker, e.g. '?'
+	params   [termMaxParams]int
+	nparams  int
+	pending  [4]uint8 // incomplete utf-8 sequence
+	npending int
+}
+
+// NewTerminal returns a Terminal covering the display, drawing with font. The
+// screen is cleared to black with light gray text and the cursor shown at the
+// top left.
+func NewTerminal(disp *Ili948x, font *Font) (*Terminal, error) {
+	const op = "NewTerminal"
+	width, height := disp.Size()
+	cw := int16(1)
+	if g := font.Glyph('M'); g != nil && g.Advance > 0 {
+		cw = int16(g.Advance)
+	}
+	ch := int16(font.Height)
+	if ch == 0 || width < cw || height < ch {
+		return nil, &Error{Op: op, Kind: ErrOutOfBounds}
+	}
+
+	t := &Terminal{
+		disp:       disp,
+		font:       font,
+		cw:         cw,
+		ch:         ch,
+		cols:       int(width / cw),
+		rows:       int(height / ch),
+		cell:       make([]uint32, int(cw)*int(ch)),
+		softScroll: disp.rot == Rot_90 || disp.rot == Rot_270,
+	}
+	t.cells = make([]termCell, t.cols*t.rows)
+	if err := t.Reset(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Size returns the number of columns and rows of the terminal.
+func (t *Terminal) Size() (cols, rows int) {
+	return t.cols, t.rows
+}
+
+// Reset returns the terminal to its initial state and clears the screen.
+func (t *Terminal) Reset() error {
+	t.col, t.row, t.wrapNext = 0, 0, false
+	t.resetAttr()
+	t.saved = termCursor{attr: t.attr, fgIndex: t.fgIndex}
+	t.autowrap, t.newline, t.cursorOn, t.shown = true, false, true, false
+	t.state = termGround
+	t.npending = 0
+	for i := range t.cells {
+		t.cells[i] = termCell{r: ' ', a: t.attr}
+	}
+
+	_, height := t.disp.Size()
+	if err := t.disp.FillScreen(t.attr.bg); err != nil {
+		return err
+	}
+	t.top, t.bottom, t.first = 0, t.rows-1, 0
+	if !t.softScroll {
+		if err := t.disp.SetScrollArea(0, uint16(height)-uint16(t.rows)*uint16(t.ch)); err != nil {
+			return err
+		}
+		if err := t.disp.SetScroll(0); err != nil {
+			return err
+		}
+	}
+	return t.showCursor()
+}
+
+// Run feeds the terminal from r until r returns an error. io.EOF ends Run
+// without an error.
+func (t *Terminal) Run(r io.Reader) error {
+	buf := make([]uint8, 64)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, err := t.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			// non-blocking readers such as machine.UART return no data
+			// instead of waiting for it
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// Write interprets p as UTF-8 text with escape sequences.
+func (t *Terminal) Write(p []uint8) (int, error) {
+	if err := t.hideCursor(); err != nil {
+		return 0, err
+	}
+	for i, b := range p {
+		if err := t.input(b); err != nil {
+			return i, err
+		}
+	}
+	return len(p), t.showCursor()
+}
+
+// WriteString writes s to the terminal.
+func (t *Terminal) WriteString(s string) (int, error) {
+	return t.Write([]uint8(s))
+}
+
+// input runs a byte through the parser.
+func (t *Terminal) input(b uint8) error {
+	// C0 controls and ESC act within escape sequences too
+	switch b {
+	case 0x18, 0x1a: // CAN, SUB
+		t.state = termGround
+		return nil
+	case 0x1b:
+		if t.state == termOSC {
+			t.state = termOSCEscape
+			return nil
+		}
+		t.state = termEscape
+		t.npending = 0
+		return nil
+	}
+
+	switch t.state {
+	case termEscape:
+		return t.escape(b)
+	case termEscapeSkip:
+		t.state = termGround
+		return nil
+	case termCSI:
+		return t.csiByte(b)
+	case termOSC, termOSCEscape:
+		if b == 0x07 || t.state == termOSCEscape { // BEL or ST
+			t.state = termGround
+		}
+		return nil
+	}
+
+	if b < 0x20 || b == 0x7f {
+		t.npending = 0
+		return t.control(b)
+	}
+
+	// utf-8 text
+	t.pending[t.npending] = b
+	t.npending++
+	if !utf8.FullRune(t.pending[:t.npending]) {
+		return nil
+	}
+	r, _ := utf8.DecodeRune(t.pending[:t.npending])
+	t.npending = 0
+	return t.put(r)
+}
+
+// control handles a C0 control character.
+func (t *Terminal) control(b uint8) error {
+	switch b {
+	case '\b':
+		t.moveTo(t.col-1, t.row)
+	case '\t':
+		col := (t.col/8 + 1) * 8
+		if col > t.cols-1 {
+			col = t.cols - 1
+		}
+		t.moveTo(col, t.row)
+	case '\n', '\v', '\f':
+		if t.newline {
+			t.col = 0
+		}
+		return t.index()
+	case '\r':
+		t.moveTo(0, t.row)
+	}
+	return nil
+}
+
+// escape handles the byte following ESC.
+func (t *Terminal) escape(b uint8) error {
+	t.state = termGround
+	switch b {
+	case '[':
+		t.state = termCSI
+		t.private = 0
+		t.nparams = 0
+		t.params[0] = 0
+	case ']', 'P', '_', '^': // OSC, DCS, APC, PM: skip the string
+		t.state = termOSC
+	case 'D': // IND
+		return t.index()
+	case 'E': // NEL
+		t.col = 0
+		return t.index()
+	case 'M': // RI
+		return t.reverseIndex()
+	case '7': // DECSC
+		t.saveCursor()
+	case '8': // DECRC
+		t.restoreCursor()
+	case 'c': // RIS
+		return t.Reset()
+	default:
+		if b >= 0x20 && b <= 0x2f { // intermediate, e.g. a charset designation
+			t.state = termEscapeSkip
+		}
+	}
+	return nil
+}
+
+// csiByte collects the parameters of a control sequence and runs it on the
+// final byte.
+func (t *Terminal) csiByte(b uint8) error {
+	switch {
+	case b >= '0' && b <= '9':
+		if t.nparams == 0 {
+			t.nparams = 1
+		}
+		if p := &t.params[t.nparams-1]; *p < 10000 {
+			*p = *p*10 + int(b-'0')
+		}
+	case b == ';' || b == ':':
+		if t.nparams == 0 {
+			t.nparams = 1
+		}
+		if t.nparams < termMaxParams {
+			t.params[t.nparams] = 0
+			t.nparams++
+		}
+	case b >= '<' && b <= '?':
+		t.private = b
+	case b >= 0x40 && b <= 0x7e:
+		t.state = termGround
+		return t.csi(b)
+	}
+	return nil
+}
+
+// param returns parameter i, or def if it is missing or 0.
+func (t *Terminal) param(i, def int) int {
+	if i >= t.nparams || t.params[i] == 0 {
+		return def
+	}
+	return t.params[i]
+}
+
+// csi runs a control sequence.
+func (t *Terminal) csi(final uint8) error {
+	if t.private != 0 && final != 'h' && final != 'l' {
+		return nil
+	}
+	n := t.param(0, 1)
+	switch final {
+	case 'A': // CUU
+		t.moveTo(t.col, t.row-n)
+	case 'B': // CUD
+		t.moveTo(t.col, t.row+n)
+	case 'C': // CUF
+		t.moveTo(t.col+n, t.row)
+	case 'D': // CUB
+		t.moveTo(t.col-n, t.row)
+	case 'E': // CNL
+		t.moveTo(0, t.row+n)
+	case 'F': // CPL
+		t.moveTo(0, t.row-n)
+	case 'G', '`': // CHA, HPA
+		t.moveTo(n-1, t.row)
+	case 'H', 'f': // CUP, HVP
+		t.moveTo(t.param(1, 1)-1, n-1)
+	case 'd': // VPA
+		t.moveTo(t.col, n-1)
+	case 'J': // ED
+		return t.eraseDisplay(t.param(0, 0))
+	case 'K': // EL
+		return t.eraseLine(t.param(0, 0))
+	case 'X': // ECH
+		return t.erase(t.row, t.col, t.col+n)
+	case '@': // ICH
+		return t.insertChars(n)
+	case 'P': // DCH
+		return t.insertChars(-n)
+	case 'L': // IL
+		return t.insertLines(n)
+	case 'M': // DL
+		return t.insertLines(-n)
+	case 'S': // SU
+		return t.scrollUp(n)
+	case 'T': // SD
+		return t.scrollDown(n)
+	case 'r': // DECSTBM
+		return t.setRegion(t.param(0, 1)-1, t.param(1, t.rows)-1)
+	case 's':
+		t.saveCursor()
+	case 'u':
+		t.restoreCursor()
+	case 'm': // SGR
+		t.sgr()
+	case 'h', 'l': // SM, RM
+		t.setModes(final == 'h')
+	}
+	return nil
+}
+
+// setModes sets or resets the modes in the parameters.
+func (t *Terminal) setModes(set bool) {
+	for i := 0; i < t.nparams; i++ {
+		switch {
+		case t.private == 0 && t.params[i] == 20:
+			t.newline = set
+		case t.private == '?' && t.params[i] == 7:
+			t.autowrap = set
+		case t.private == '?' && t.params[i] == 25:
+			t.cursorOn = set
+		}
+	}
+}
+
+// sgr applies select graphic rendition parameters to the attributes.
+func (t *Terminal) sgr() {
+	if t.nparams == 0 {
+		t.resetAttr()
+		return
+	}
+	for i := 0; i < t.nparams; i++ {
+		p := t.params[i]
+		switch {
+		case p == 0:
+			t.resetAttr()
+		case p == 1:
+			t.attr.flags |= termBold
+		case p == 4:
+			t.attr.flags |= termUnderline
+		case p == 7:
+			t.attr.flags |= termInverse
+		case p == 22:
+			t.attr.flags &^= termBold
+		case p == 24:
+			t.attr.flags &^= termUnderline
+		case p == 27:
+			t.attr.flags &^= termInverse
+		case p >= 30 && p <= 37:
+			t.fgIndex = p - 30
+		case p == 38, p == 48:
+			c, n, ok := t.extendedColor(i + 1)
+			i += n
+			if !ok {
+				continue
+			}
+			if p == 38 {
+				t.attr.fg, t.fgIndex = c, -1
+			} else {
+				t.attr.bg = c
+			}
+		case p == 39:
+			t.fgIndex = 7
+		case p >= 40 && p <= 47:
+			t.attr.bg = xtermColor(p - 40)
+		case p == 49:
+			t.attr.bg = xtermColor(0)
+		case p >= 90 && p <= 97:
+			t.fgIndex = p - 90 + 8
+		case p >= 100 && p <= 107:
+			t.attr.bg = xtermColor(p - 100 + 8)
+		}
+	}
+
+	// bold brightens the first 8 palette colors
+	if t.fgIndex >= 0 {
+		i := t.fgIndex
+		if i < 8 && t.attr.flags&termBold != 0 {
+			i += 8
+		}
+		t.attr.fg = xtermColor(i)
+	}
+}
+
+// extendedColor decodes the 256 color (5;n) or 24 bit color (2;r;g;b)
+// parameters from i, returning the color and the parameters used.
+func (t *Terminal) extendedColor(i int) (uint32, int, bool) {
+	if i >= t.nparams {
+		return 0, 0, false
+	}
+	switch t.params[i] {
+	case 5:
+		if i+1 >= t.nparams {
+			return 0, 1, false
+		}
+		return xtermColor(t.params[i+1] & 0xff), 2, true
+	case 2:
+		if i+3 >= t.nparams {
+			return 0, t.nparams - i, false
+		}
+		r, g, b := uint32(t.params[i+1]&0xff), uint32(t.params[i+2]&0xff), uint32(t.params[i+3]&0xff)
+		return r<<16 | g<<8 | b, 4, true
+	}
+	return 0, 1, false
+}
+
+// resetAttr sets the default attributes: light gray on black.
+func (t *Terminal) resetAttr() {
+	t.fgIndex = 7
+	t.attr = termAttr{fg: xtermColor(7), bg: xtermColor(0)}
+}
+
+func (t *Terminal) saveCursor() {
+	t.saved = termCursor{col: t.col, row: t.row, attr: t.attr, fgIndex: t.fgIndex}
+}
+
+func (t *Terminal) restoreCursor() {
+	t.attr, t.fgIndex = t.saved.attr, t.saved.fgIndex
+	t.moveTo(t.saved.col, t.saved.row)
+}
+
+// moveTo moves the cursor, keeping it on the screen.
+func (t *Terminal) moveTo(col, row int) {
+	t.col = clampInt(col, 0, t.cols-1)
+	t.row = clampInt(row, 0, t.rows-1)
+	t.wrapNext = false
+}
+
+// put draws r at the cursor and advances it. With autowrap the cursor wraps
+// to the next line before the next character past the last column.
+func (t *Terminal) put(r rune) error {
+	if t.wrapNext {
+		t.col, t.wrapNext = 0, false
+		if err := t.index(); err != nil {
+			return err
+		}
+	}
+	t.cells[t.row*t.cols+t.col] = termCell{r: r, a: t.attr}
+	if err := t.drawCell(t.col, t.row, false); err != nil {
+		return err
+	}
+	if t.col < t.cols-1 {
+		t.col++
+	} else if t.autowrap {
+		t.wrapNext = true
+	}
+	return nil
+}
+
+// index moves the cursor down a row, scrolling the region up at its bottom.
+func (t *Terminal) index() error {
+	t.wrapNext = false
+	if t.row == t.bottom {
+		return t.scrollUp(1)
+	}
+	if t.row < t.rows-1 {
+		t.row++
+	}
+	return nil
+}
+
+// reverseIndex moves the cursor up a row, scrolling the region down at its top.
+func (t *Terminal) reverseIndex() error {
+	t.wrapNext = false
+	if t.row == t.top {
+		return t.scrollDown(1)
+	}
+	if t.row > 0 {
+		t.row--
+	}
+	return nil
+}
+
+// regionLines returns the first display line and the number of lines of the
+// scroll region.
+func (t *Terminal) regionLines() (uint16, uint16) {
+	return uint16(t.top) * uint16(t.ch), uint16(t.bottom-t.top+1) * uint16(t.ch)
+}
+
+// line returns the display line of the top of a row.
+func (t *Terminal) line(row int) uint16 {
+	if row < t.top || row > t.bottom {
+		return uint16(row) * uint16(t.ch)
+	}
+	tfa, vsa := t.regionLines()
+	return tfa + (t.first+uint16(row-t.top)*uint16(t.ch))%vsa
+}
+
+// setRegion sets the scroll region and the hardware scroll area to the rows
+// from top to bottom and homes the cursor.
+func (t *Terminal) setRegion(top, bottom int) error {
+	if bottom > t.rows-1 {
+		bottom = t.rows - 1
+	}
+	if top < 0 || top >= bottom {
+		return nil
+	}
+
+	// rows of a scrolled region are out of order in the frame memory, redraw
+	// them in place for the new region
+	redraw := t.first != 0
+	t.top, t.bottom, t.first = top, bottom, 0
+	if t.softScroll {
+		t.moveTo(0, 0)
+		return nil
+	}
+	_, height := t.disp.Size()
+	tfa, vsa := t.regionLines()
+	if err := t.disp.SetScrollArea(tfa, uint16(height)-tfa-vsa); err != nil {
+		return err
+	}
+	if err := t.disp.SetScroll(tfa); err != nil {
+		return err
+	}
+	if redraw {
+		if err := t.drawRows(0, t.rows); err != nil {
+			return err
+		}
+	}
+	t.moveTo(0, 0)
+	return nil
+}
+
+// scrollUp scrolls the region up n rows, with the hardware scroll unless
+// softScroll is set, clearing the rows coming in at the bottom.
+func (t *Terminal) scrollUp(n int) error {
+	n = clampInt(n, 0, t.bottom-t.top+1)
+	if n == 0 {
+		return nil
+	}
+	copy(t.cells[t.top*t.cols:(t.bottom+1)*t.cols], t.cells[(t.top+n)*t.cols:(t.bottom+1)*t.cols])
+	for row := t.bottom - n + 1; row < t.bottom; row++ {
+		t.blank(row, 0, t.cols)
+	}
+	if t.softScroll {
+		t.blank(t.bottom, 0, t.cols)
+		return t.drawRows(t.top, t.bottom+1)
+	}
+	_, vsa := t.regionLines()
+	for i := 0; i < n; i++ {
+		// the top row becomes the bottom row
+		t.first = (t.first + uint16(t.ch)) % vsa
+		if err := t.erase(t.bottom, 0, t.cols); err != nil {
+			return err
+		}
+	}
+	return t.disp.scrollForward(t.first)
+}
+
+// scrollDown scrolls the region down n rows, with the hardware scroll unless
+// softScroll is set, clearing the rows coming in at the top.
+func (t *Terminal) scrollDown(n int) error {
+	n = clampInt(n, 0, t.bottom-t.top+1)
+	if n == 0 {
+		return nil
+	}
+	copy(t.cells[(t.top+n)*t.cols:(t.bottom+1)*t.cols], t.cells[t.top*t.cols:(t.bottom+1)*t.cols])
+	for row := t.top + 1; row < t.top+n; row++ {
+		t.blank(row, 0, t.cols)
+	}
+	if t.softScroll {
+		t.blank(t.top, 0, t.cols)
+		return t.drawRows(t.top, t.bottom+1)
+	}
+	_, vsa := t.regionLines()
+	for i := 0; i < n; i++ {
+		// the bottom row becomes the top row
+		t.first = (t.first + vsa - uint16(t.ch)) % vsa
+		if err := t.erase(t.top, 0, t.cols); err != nil {
+			return err
+		}
+	}
+	return t.disp.scrollForward(t.first)
+}
+
+// insertLines inserts n blank lines at the cursor row, or deletes -n lines,
+// moving the rows below within the scroll region.
+func (t *Terminal) insertLines(n int) error {
+	if t.row < t.top || t.row > t.bottom {
+		return nil
+	}
+	t.col, t.wrapNext = 0, false
+	end := (t.bottom + 1) * t.cols
+	blank := t.row
+	if n > 0 {
+		n = clampInt(n, 0, t.bottom-t.row+1)
+		copy(t.cells[(t.row+n)*t.cols:end], t.cells[t.row*t.cols:end])
+	} else {
+		n = clampInt(-n, 0, t.bottom-t.row+1)
+		copy(t.cells[t.row*t.cols:end], t.cells[(t.row+n)*t.cols:end])
+		blank = t.bottom - n + 1
+	}
+	for row := blank; row < blank+n; row++ {
+		t.blank(row, 0, t.cols)
+	}
+	return t.drawRows(t.row, t.bottom+1)
+}
+
+// insertChars inserts n blank characters at the cursor, or deletes -n
+// characters, moving the rest of the line.
+func (t *Terminal) insertChars(n int) error {
+	t.wrapNext = false
+	line := t.cells[t.row*t.cols : (t.row+1)*t.cols]
+	cols := t.cols - t.col
+	if n > 0 {
+		n = clampInt(n, 0, cols)
+		copy(line[t.col+n:], line[t.col:])
+		t.blank(t.row, t.col, t.col+n)
+	} else {
+		n = clampInt(-n, 0, cols)
+		copy(line[t.col:], line[t.col+n:])
+		t.blank(t.row, t.cols-n, t.cols)
+	}
+	for col := t.col; col < t.cols; col++ {
+		if err := t.drawCell(col, t.row, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eraseDisplay clears below (0), above (1) or all of the screen (2, 3).
+func (t *Terminal) eraseDisplay(mode int) error {
+	from, to := 0, t.rows
+	switch mode {
+	case 0:
+		if err := t.eraseLine(0); err != nil {
+			return err
+		}
+		from = t.row + 1
+	case 1:
+		if err := t.eraseLine(1); err != nil {
+			return err
+		}
+		to = t.row
+	}
+	for row := from; row < to; row++ {
+		if err := t.erase(row, 0, t.cols); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eraseLine clears the line right (0) or left (1) of the cursor including it,
+// or all of it (2).
+func (t *Terminal) eraseLine(mode int) error {
+	switch mode {
+	case 0:
+		return t.erase(t.row, t.col, t.cols)
+	case 1:
+		return t.erase(t.row, 0, t.col+1)
+	}
+	return t.erase(t.row, 0, t.cols)
+}
+
+// blank clears the cells from column from up to to in the screen buffer with
+// the current background color.
+func (t *Terminal) blank(row, from, to int) {
+	a := termAttr{fg: t.attr.fg, bg: t.attr.bg}
+	for col := from; col < to; col++ {
+		t.cells[row*t.cols+col] = termCell{r: ' ', a: a}
+	}
+}
+
+// erase clears the cells from column from up to to.
+func (t *Terminal) erase(row, from, to int) error {
+	to = clampInt(to, 0, t.cols)
+	if from >= to {
+		return nil
+	}
+	t.blank(row, from, to)
+	x := int16(from) * t.cw
+	w := int16(to-from) * t.cw
+	if to == t.cols {
+		width, _ := t.disp.Size()
+		w = width - x // include the margin right of the last column
+	}
+	return t.disp.FillRectangle(x, int16(t.line(row)), w, t.ch, t.attr.bg)
+}
+
+// drawRows redraws the rows from up to to from the screen buffer.
+func (t *Terminal) drawRows(from, to int) error {
+	for row := from; row < to; row++ {
+		for col := 0; col < t.cols; col++ {
+			if err := t.drawCell(col, row, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// drawCell draws a cell from the screen buffer, in inverse for the cursor.
+func (t *Terminal) drawCell(col, row int, cursor bool) error {
+	c := t.cells[row*t.cols+col]
+	fg, bg := c.a.fg, c.a.bg
+	if (c.a.flags&termInverse != 0) != cursor {
+		fg, bg = bg, fg
+	}
+	w, h := int(t.cw), int(t.ch)
+	t.font.renderCell(t.cell, w, h, t.font.Glyph(c.r), fg, bg)
+	if c.a.flags&termUnderline != 0 {
+		if y := int(t.font.Ascent) + 1; y < h {
+			for x := 0; x < w; x++ {
+				t.cell[y*w+x] = fg
+			}
+		}
+	}
+	return t.disp.writeWindow(uint16(col)*uint16(t.cw), t.line(row), uint16(t.cw), uint16(t.ch), t.cell)
+}
+
+// showCursor draws the cursor if it is on.
+func (t *Terminal) showCursor() error {
+	if !t.cursorOn {
+		return nil
+	}
+	t.shown = true
+	return t.drawCell(t.col, t.row, true)
+}
+
+// hideCursor restores the cell under the cursor.
+func (t *Terminal) hideCursor() error {
+	if !t.shown {
+		return nil
+	}
+	t.shown = false
+	return t.drawCell(t.col, t.row, false)
+}
+
+// xtermColor returns color i of the xterm 256 color palette: 16 system
+// colors, a 6x6x6 color cube and 24 grays.
+func xtermColor(i int) uint32 {
+	system := [16]uint32{
+		0x000000, 0xcd0000, 0x00cd00, 0xcdcd00, 0x0000ee, 0xcd00cd, 0x00cdcd, 0xe5e5e5,
+		0x7f7f7f, 0xff0000, 0x00ff00, 0xffff00, 0x5c5cff, 0xff00ff, 0x00ffff, 0xffffff,
+	}
+	switch {
+	case i < 16:
+		return system[i]
+	case i < 232:
+		i -= 16
+		level := func(v int) uint32 {
+			if v == 0 {
+				return 0
+			}
+			return uint32(55 + v*40)
+		}
+		return level(i/36)<<16 | level(i/6%6)<<8 | level(i%6)
+	}
+	v := uint32(8 + (i-232)*10)
+	return v<<16 | v<<8 | v
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
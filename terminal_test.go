@@ -0,0 +1,337 @@
+package ili948x_test
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/sim"
+)
+
+// newTerminal returns a terminal on a simulated display.
+func newTerminal(t *testing.T) (*ili948x.Terminal, *ili948x.Ili948x, *sim.Display) {
+	t.Helper()
+	return newTerminalAt(t, ili948x.Rot_0, false)
+}
+
+// newTerminalAt returns a terminal on a simulated display in the given
+// rotation and scroll direction.
+func newTerminalAt(t *testing.T, rot ili948x.Rotation, reverse bool) (*ili948x.Terminal, *ili948x.Ili948x, *sim.Display) {
+	t.Helper()
+	disp, d := newSimulated(t)
+	if err := disp.SetRotation(rot); err != nil {
+		t.Fatal(err)
+	}
+	if err := disp.SetScrollReverse(reverse); err != nil {
+		t.Fatal(err)
+	}
+	term, err := ili948x.NewTerminal(disp, ili948x.Font7x13)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return term, disp, d
+}
+
+func write(t *testing.T, term *ili948x.Terminal, s string) {
+	t.Helper()
+	if n, err := term.WriteString(s); err != nil || n != len(s) {
+		t.Fatalf("write %q: %d, %v", s, n, err)
+	}
+}
+
+// row returns the characters of a row without trailing spaces.
+func row(term *ili948x.Terminal, y int) string {
+	cols, _ := term.Size()
+	var sb strings.Builder
+	for x := 0; x < cols; x++ {
+		r, _, _, _ := term.Cell(x, y)
+		sb.WriteRune(r)
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+func checkCursor(t *testing.T, term *ili948x.Terminal, col, row int) {
+	t.Helper()
+	if c, r := term.Cursor(); c != col || r != row {
+		t.Errorf("cursor at (%d, %d), want (%d, %d)", c, r, col, row)
+	}
+}
+
+func TestTerminalSize(t *testing.T) {
+	term, _, _ := newTerminal(t)
+	if cols, rows := term.Size(); cols != 320/7 || rows != 480/13 {
+		t.Errorf("size %dx%d, want %dx%d", cols, rows, 320/7, 480/13)
+	}
+}
+
+func TestTerminalCursor(t *testing.T) {
+	term, _, _ := newTerminal(t)
+	cols, rows := term.Size()
+	for _, tt := range []struct {
+		seq      string
+		col, row int
+	}{
+		{"ab\r\nc", 1, 1},
+		{"\x1b[5;10H", 9, 4},
+		{"\x1b[2A", 9, 2},
+		{"\x1b[B", 9, 3},
+		{"\x1b[3C", 12, 3},
+		{"\x1b[100D", 0, 3},
+		{"\x1b[7G", 6, 3},
+		{"\x1b[2d", 6, 1},
+		{"\x1b[;4f", 3, 0},
+		{"\x1b[2E", 0, 2},
+		{"\x1b[F", 0, 1},
+		{"\x1b[999;999H", cols - 1, rows - 1},
+		{"\x1b[H", 0, 0},
+		{"\t", 8, 0},
+		{"\t\t", 24, 0},
+		{"\b\b", 22, 0},
+		{"\x1b[s\x1b[10;10H\x1b[u", 22, 0},
+		{"\x1b7\x1b[10;10H\x1b8", 22, 0},
+		{"\n\n", 22, 2},
+		{"\x1b[20h\n", 0, 3}, // LNM
+		{"\x1b[20l\x1b[5G\n", 4, 4},
+		{"\x1bD", 4, 5}, // IND
+		{"\x1bE", 0, 6}, // NEL
+		{"\x1bM", 0, 5}, // RI
+	} {
+		write(t, term, tt.seq)
+		checkCursor(t, term, tt.col, tt.row)
+	}
+	if got := row(term, 0); got != "ab" {
+		t.Errorf("row 0 %q, want %q", got, "ab")
+	}
+	if got := row(term, 1); got != "c" {
+		t.Errorf("row 1 %q, want %q", got, "c")
+	}
+}
+
+func TestTerminalText(t *testing.T) {
+	term, _, _ := newTerminal(t)
+	cols, _ := term.Size()
+
+	// utf-8 split across writes, OSC and charset designations skipped
+	write(t, term, "\xc3")
+	write(t, term, "\xa9\x1b]0;title\x07x\x1b]2;t\x1b\\y\x1b(Bz")
+	if got := row(term, 0); got != "éxyz" {
+		t.Errorf("row 0 %q, want %q", got, "éxyz")
+	}
+
+	// autowrap after the last column
+	write(t, term, "\x1b[2;1H"+strings.Repeat("a", cols)+"b")
+	if got := row(term, 2); got != "b" {
+		t.Errorf("wrapped row %q, want %q", got, "b")
+	}
+	write(t, term, "\x1b[?7l\x1b[4;1H"+strings.Repeat("c", cols)+"de")
+	if got, want := row(term, 3), strings.Repeat("c", cols-1)+"e"; got != want {
+		t.Errorf("row without autowrap %q, want %q", got, want)
+	}
+	if got := row(term, 4); got != "" {
+		t.Errorf("row after no autowrap %q, want empty", got)
+	}
+
+	// CAN aborts a sequence
+	write(t, term, "\x1b[5;1H\x1b[3\x18m")
+	if got := row(term, 4); got != "m" {
+		t.Errorf("row 4 %q, want %q", got, "m")
+	}
+}
+
+func TestTerminalErase(t *testing.T) {
+	term, _, _ := newTerminal(t)
+	for _, tt := range []struct {
+		seq  string
+		rows []string
+	}{
+		{"0123456789\r\n0123456789\r\n0123456789", []string{"0123456789", "0123456789", "0123456789"}},
+		{"\x1b[1;4H\x1b[K", []string{"012", "0123456789", "0123456789"}},
+		{"\x1b[2;4H\x1b[1K", []string{"012", "    456789", "0123456789"}},
+		{"\x1b[3;4H\x1b[2X", []string{"012", "    456789", "012  56789"}},
+		{"\x1b[3;2H\x1b[2P", []string{"012", "    456789", "0  56789"}},
+		{"\x1b[3;2H\x1b[3@", []string{"012", "    456789", "0     56789"}},
+		{"\x1b[2;1H\x1b[L", []string{"012", "", "    456789", "0     56789"}},
+		{"\x1b[1;1H\x1b[2M", []string{"    456789", "0     56789", ""}},
+		{"\x1b[2;5H\x1b[J", []string{"    456789", "0", "", ""}},
+		{"\x1b[1;1Habc\r\ndef\x1b[1;2H\x1b[1J", []string{"  c 456789", "def"}},
+		{"\x1b[2J", []string{"", ""}},
+	} {
+		write(t, term, tt.seq)
+		for y, want := range tt.rows {
+			if got := row(term, y); got != want {
+				t.Errorf("%q: row %d %q, want %q", tt.seq, y, got, want)
+			}
+		}
+	}
+}
+
+func TestTerminalSGR(t *testing.T) {
+	term, _, _ := newTerminal(t)
+	def := ili948x.XtermColor
+	for _, tt := range []struct {
+		seq    string
+		fg, bg uint32
+		flags  uint8
+	}{
+		{"", def(7), def(0), 0},
+		{"\x1b[31m", def(1), def(0), 0},
+		{"\x1b[1;31m", def(9), def(0), ili948x.TermBold},
+		{"\x1b[31m\x1b[1m", def(9), def(0), ili948x.TermBold},
+		{"\x1b[1;31m\x1b[22m", def(1), def(0), 0},
+		{"\x1b[42m", def(7), def(2), 0},
+		{"\x1b[97;104m", def(15), def(12), 0},
+		{"\x1b[38;5;196m", def(196), def(0), 0},
+		{"\x1b[48;5;244m", def(7), def(244), 0},
+		{"\x1b[38;2;10;20;30;48;2;40;50;60m", 0x0a141e, 0x28323c, 0},
+		{"\x1b[38:2:10:20:30m", 0x0a141e, def(0), 0},
+		{"\x1b[1;38;2;1;2;3m", 0x010203, def(0), ili948x.TermBold}, // no bold brightening
+		{"\x1b[4;7m", def(7), def(0), ili948x.TermUnderline | ili948x.TermInverse},
+		{"\x1b[4;7m\x1b[24;27m", def(7), def(0), 0},
+		{"\x1b[31;44m\x1b[39;49m", def(7), def(0), 0},
+		{"\x1b[31;4;0m", def(7), def(0), 0},
+		{"\x1b[31;4m\x1b[m", def(7), def(0), 0},
+		{"\x1b[38;5m", def(7), def(0), 0}, // incomplete
+		{"\x1b[38;2;1;2m", def(7), def(0), 0},
+		{"\x1b[38;9;1m", def(15), def(0), ili948x.TermBold}, // unknown color space, 1 is bold
+		{"\x1b[?31m", def(7), def(0), 0},                    // private, ignored
+	} {
+		write(t, term, "\x1b[0m\x1b[H"+tt.seq+"X")
+		r, fg, bg, flags := term.Cell(0, 0)
+		if r != 'X' || fg != tt.fg || bg != tt.bg || flags != tt.flags {
+			t.Errorf("%q: cell %q fg %06x bg %06x flags %d, want fg %06x bg %06x flags %d",
+				tt.seq, r, fg, bg, flags, tt.fg, tt.bg, tt.flags)
+		}
+	}
+
+	// erased cells take the background color
+	write(t, term, "\x1b[0;44m\x1b[2J")
+	if _, _, bg, _ := term.Cell(5, 5); bg != def(4) {
+		t.Errorf("erased cell bg %06x, want %06x", bg, def(4))
+	}
+}
+
+func TestTerminalXtermColors(t *testing.T) {
+	for _, tt := range []struct {
+		i    int
+		want uint32
+	}{
+		{0, 0x000000},
+		{15, 0xffffff},
+		{16, 0x000000},
+		{21, 0x0000ff},
+		{196, 0xff0000},
+		{231, 0xffffff},
+		{232, 0x080808},
+		{255, 0xeeeeee},
+	} {
+		if got := ili948x.XtermColor(tt.i); got != tt.want {
+			t.Errorf("color %d: got %06x, want %06x", tt.i, got, tt.want)
+		}
+	}
+}
+
+func TestTerminalScrollRegion(t *testing.T) {
+	term, disp, _ := newTerminal(t)
+	_, rows := term.Size()
+	const ch = 13
+	_, height := disp.Size()
+
+	// full screen region
+	if top, bottom := term.Region(); top != 0 || bottom != rows-1 {
+		t.Errorf("region %d-%d, want 0-%d", top, bottom, rows-1)
+	}
+
+	write(t, term, "\x1b[1;1Htop\x1b[7;1Hbottom\x1b[3;6r")
+	if top, bottom := term.Region(); top != 2 || bottom != 5 {
+		t.Errorf("region %d-%d, want 2-5", top, bottom)
+	}
+	checkCursor(t, term, 0, 0)
+	tfa, vsa, bfa := disp.GetScrollArea()
+	if tfa != 2*ch || vsa != 4*ch || bfa != uint16(height)-6*ch {
+		t.Errorf("scroll area %d %d %d, want %d %d %d", tfa, vsa, bfa, 2*ch, 4*ch, uint16(height)-6*ch)
+	}
+
+	// LF at the bottom of the region scrolls it
+	write(t, term, "\x1b[3;1Ha\r\nb\r\nc\r\nd\r\ne\r\nf")
+	want := []string{"top", "", "c", "d", "e", "f", "bottom"}
+	for y, w := range want {
+		if got := row(term, y); got != w {
+			t.Errorf("row %d %q, want %q", y, got, w)
+		}
+	}
+	if got := disp.GetScroll(); got != tfa+2*ch {
+		t.Errorf("scroll line %d, want %d", got, tfa+2*ch)
+	}
+
+	// RI at the top scrolls it down, SU and SD scroll without moving the cursor
+	write(t, term, "\x1b[3;1H\x1bMz\x1b[2S\x1b[T")
+	want = []string{"top", "", "", "d", "e", "", "bottom"}
+	for y, w := range want {
+		if got := row(term, y); got != w {
+			t.Errorf("after RI, SU, SD: row %d %q, want %q", y, got, w)
+		}
+	}
+
+	// outside of the region LF does not scroll
+	write(t, term, "\x1b[7;1H\n\n")
+	checkCursor(t, term, 0, 8)
+
+	// invalid regions are ignored, CSI r resets to the full screen
+	write(t, term, "\x1b[6;3r")
+	if top, bottom := term.Region(); top != 2 || bottom != 5 {
+		t.Errorf("region %d-%d after an invalid one, want 2-5", top, bottom)
+	}
+	write(t, term, "\x1b[r")
+	if top, bottom := term.Region(); top != 0 || bottom != rows-1 {
+		t.Errorf("region %d-%d, want 0-%d", top, bottom, rows-1)
+	}
+	if got := disp.GetScroll(); got != 0 {
+		t.Errorf("scroll line %d, want 0", got)
+	}
+	want = []string{"top", "", "", "d", "e", "", "bottom"}
+	for y, w := range want {
+		if got := row(term, y); got != w {
+			t.Errorf("after reset: row %d %q, want %q", y, got, w)
+		}
+	}
+}
+
+// TestTerminalScrollScreen checks the panel shows a scrolled region like the
+// same text written without scrolling.
+func TestTerminalScrollScreen(t *testing.T) {
+	for _, tt := range scrollModes {
+		term, _, d := newTerminalAt(t, tt.rot, tt.reverse)
+		write(t, term, "\x1b[?25l\x1b[1;1Hheader\x1b[8;1Hfooter\x1b[2;7r\x1b[2;1H")
+		// 22 lines leave the region scrolled by 5 of its 6 rows, as half of
+		// it would look the same in either scroll direction
+		for i := 0; i < 22; i++ {
+			write(t, term, "\x1b[3"+strconv.Itoa(1+i%7)+"mline "+strconv.Itoa(i)+"\r\n")
+		}
+		write(t, term, "\x1b[2;3H\x1b[2L\x1b[4;1H\x1b[M")
+
+		// the same screen drawn without scrolling
+		ref, _, refd := newTerminalAt(t, tt.rot, tt.reverse)
+		write(t, ref, "\x1b[?25l")
+		cols, rows := term.Size()
+		for y := 0; y < rows; y++ {
+			for x := 0; x < cols; x++ {
+				r, fg, bg, _ := term.Cell(x, y)
+				if r == ' ' && bg == 0 {
+					continue
+				}
+				// the 16 color palette index of the foreground
+				for i := 0; i < 8; i++ {
+					if ili948x.XtermColor(i) == fg {
+						write(t, ref, "\x1b["+strconv.Itoa(y+1)+";"+strconv.Itoa(x+1)+"H\x1b[3"+strconv.Itoa(i)+"m"+string(r))
+					}
+				}
+			}
+		}
+		if row(term, 0) != "header" || row(term, 7) != "footer" {
+			t.Errorf("%v: fixed rows %q, %q", tt, row(term, 0), row(term, 7))
+		}
+		checkImage(t, fmt.Sprint(tt), d.Image(), refd.Image())
+	}
+}
@@ -0,0 +1,263 @@
+package ili948x
+
+import (
+	"strings"
+)
+
+// Align is the horizontal alignment of text lines in a box.
+type Align uint8
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// DrawText draws UTF-8 text in colors fg on bg with its top-left corner at
+// x, y. Lines are separated by \n. Each line is written as one window, row by
+// row, and is clipped to the display.
+func (disp *Ili948x) DrawText(x, y int16, s string, font *Font, fg, bg uint32) error {
+	for {
+		line, rest, more := strings.Cut(s, "\n")
+		if err := disp.drawTextLine(x, y, int16(textWidth(line, font)), int16(font.Height), line, 0, font, fg, bg); err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+		s = rest
+		y += int16(font.Height)
+	}
+}
+
+// DrawTextBox draws UTF-8 text in colors fg on bg, word wrapped to fit the
+// box and aligned within it. Lines are separated by \n and words too wide
+// for the box are broken between characters. Lines beyond the bottom of the
+// box are clipped and the rest of the box is filled with bg.
+func (disp *Ili948x) DrawTextBox(x, y, width, height int16, s string, font *Font, fg, bg uint32, align Align) error {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	ly := y
+	for _, line := range WrapText(s, font, int(width)) {
+		rows := int16(font.Height)
+		if rows > y+height-ly {
+			rows = y + height - ly
+		}
+		if rows <= 0 {
+			break
+		}
+
+		offs := 0
+		switch align {
+		case AlignCenter:
+			offs = (int(width) - textWidth(line, font)) / 2
+		case AlignRight:
+			offs = int(width) - textWidth(line, font)
+		}
+		if err := disp.drawTextLine(x, ly, width, rows, line, offs, font, fg, bg); err != nil {
+			return err
+		}
+		ly += rows
+	}
+
+	if ly < y+height {
+		return disp.FillRectangle(x, ly, width, y+height-ly, bg)
+	}
+	return nil
+}
+
+// MeasureText returns the width of the widest line of s and the height of
+// its lines, with lines separated by \n.
+func MeasureText(s string, font *Font) (width, height int16) {
+	for {
+		line, rest, more := strings.Cut(s, "\n")
+		if w := int16(textWidth(line, font)); w > width {
+			width = w
+		}
+		height += int16(font.Height)
+		if !more {
+			return width, height
+		}
+		s = rest
+	}
+}
+
+// DrawTextOver draws UTF-8 text in color fg at x, y like DrawText, blending
+// anti-aliased glyphs with the pixels already on the display. Each pixel row of
+// a line is read back with CMD_RAMRD before it is written, so the transport
+// must implement ReadTransport.
+func (disp *Ili948x) DrawTextOver(x, y int16, s string, font *Font, fg uint32) error {
+	if _, ok := disp.trans.(ReadTransport); !ok {
+		return &Error{Op: "DrawTextOver", Kind: ErrNotSupported}
+	}
+	for {
+		line, rest, more := strings.Cut(s, "\n")
+		if err := disp.drawTextLineOver(x, y, line, font, fg); err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+		s = rest
+		y += int16(font.Height)
+	}
+}
+
+// drawTextLine draws a line of text in a window of width by rows pixels at x, y,
+// with the pen starting offs pixels right of x. The window is written with a
+// single CMD_RAMWR, one pixel row at a time.
+func (disp *Ili948x) drawTextLine(x, y, width, rows int16, s string, offs int, font *Font, fg, bg uint32) error {
+	cx, cy, cw, ch, ok := disp.clip(int32(x), int32(y), int32(width), int32(rows))
+	if !ok {
+		return nil
+	}
+	disp.layoutText(s, offs, font)
+	row := disp.textRowBuf(int(width))
+	left := int(cx) - int(x)
+
+	if err := disp.startRAMWR(cx, cy, cw, ch); err != nil {
+		return err
+	}
+	for py := int(cy) - int(y); py < int(cy)-int(y)+int(ch); py++ {
+		for i := range row {
+			row[i] = bg
+		}
+		disp.renderTextRow(row, py, font, fg)
+		if err := disp.writePixels(row[left : left+int(cw)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drawTextLineOver draws a line of text at x, y over the frame memory, reading
+// back and writing each visible pixel row.
+func (disp *Ili948x) drawTextLineOver(x, y int16, s string, font *Font, fg uint32) error {
+	width := textWidth(s, font)
+	cx, cy, cw, ch, ok := disp.clip(int32(x), int32(y), int32(width), int32(font.Height))
+	if !ok {
+		return nil
+	}
+	disp.layoutText(s, 0, font)
+	row := disp.textRowBuf(width)
+	left := int(cx) - int(x)
+	vis := row[left : left+int(cw)]
+
+	for py := int(cy) - int(y); py < int(cy)-int(y)+int(ch); py++ {
+		ry := int16(y) + int16(py)
+		if err := disp.ReadRectangle(int16(cx), ry, int16(cw), 1, vis); err != nil {
+			return err
+		}
+		disp.renderTextRow(row, py, font, fg)
+		if err := disp.writeWindow(cx, uint16(ry), cw, 1, vis); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// layoutText collects the glyphs of s and their pen positions, starting at offs.
+func (disp *Ili948x) layoutText(s string, offs int, font *Font) {
+	glyphs := disp.textGlyphs[:0]
+	pens := disp.textPens[:0]
+	pen := offs
+	for _, r := range s {
+		g := font.Glyph(r)
+		if g == nil {
+			continue
+		}
+		glyphs = append(glyphs, g)
+		pens = append(pens, pen)
+		pen += int(g.Advance)
+	}
+	disp.textGlyphs, disp.textPens = glyphs, pens
+}
+
+// textRowBuf returns the reused row buffer, width pixels long.
+func (disp *Ili948x) textRowBuf(width int) []uint32 {
+	if cap(disp.textRow) < width {
+		disp.textRow = make([]uint32, width)
+	}
+	return disp.textRow[:width]
+}
+
+// renderTextRow blends pixel row py of the laid out glyphs into row in color fg.
+func (disp *Ili948x) renderTextRow(row []uint32, py int, font *Font, fg uint32) {
+	for i, g := range disp.textGlyphs {
+		gy := py - int(font.Ascent) - int(g.YOffset)
+		if gy < 0 || gy >= int(g.Height) {
+			continue
+		}
+		px := disp.textPens[i] + int(g.XOffset)
+		for gx := 0; gx < int(g.Width); gx++ {
+			if px+gx >= 0 && px+gx < len(row) {
+				row[px+gx] = blend(fg, row[px+gx], font.alpha(g, gx, gy))
+			}
+		}
+	}
+}
+
+// advance returns the pen advance of r, 0 if it has no glyph.
+func (f *Font) advance(r rune) int {
+	if g := f.Glyph(r); g != nil {
+		return int(g.Advance)
+	}
+	return 0
+}
+
+// textWidth returns the width of a line of text.
+func textWidth(s string, font *Font) int {
+	w := 0
+	for _, r := range s {
+		w += font.advance(r)
+	}
+	return w
+}
+
+// WrapText splits s into lines at \n and word wraps them to width, as
+// DrawTextBox draws them.
+func WrapText(s string, font *Font, width int) []string {
+	var lines []string
+	for {
+		line, rest, more := strings.Cut(s, "\n")
+		lines = wrapLine(lines, line, font, width)
+		if !more {
+			return lines
+		}
+		s = rest
+	}
+}
+
+// wrapLine appends the word wrapped lines of s to lines. Lines break at the
+// last space that fits, or between characters within a word too wide to fit,
+// with at least one character on each line.
+func wrapLine(lines []string, s string, font *Font, width int) []string {
+	for {
+		w, end, space := 0, len(s), -1
+		for i, r := range s {
+			if r == ' ' {
+				space = i
+			}
+			adv := font.advance(r)
+			if w+adv > width && i > 0 {
+				end = i
+				break
+			}
+			w += adv
+		}
+		if end == len(s) {
+			return append(lines, s)
+		}
+
+		brk, next := end, end
+		if space > 0 {
+			brk, next = space, space+1
+		}
+		lines = append(lines, strings.TrimRight(s[:brk], " "))
+		s = strings.TrimLeft(s[next:], " ")
+		if s == "" {
+			return lines
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package ili948x_test
+
+import (
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/inindev/ili948x"
+	"github.com/inindev/ili948x/sim"
+)
+
+func TestWrapText(t *testing.T) {
+	font := ili948x.Font7x13 // 7 pixels per character
+	for _, tt := range []struct {
+		s     string
+		width int
+		want  []string
+	}{
+		{"", 70, []string{""}},
+		{"hello", 70, []string{"hello"}},
+		{"abcd", 28, []string{"abcd"}}, // exact fit
+		{"a\n\nb", 70, []string{"a", "", "b"}},
+		{"hello world", 70, []string{"hello", "world"}},
+		{"hello   world", 70, []string{"hello", "world"}},
+		{"one two three", 63, []string{"one two", "three"}},
+		{"ab cd", 14, []string{"ab", "cd"}},
+		{"ab   ", 14, []string{"ab"}},
+		{"abcdefghij", 28, []string{"abcd", "efgh", "ij"}},
+		{"ab abcdefgh", 28, []string{"ab", "abcd", "efgh"}},
+		{"abc", 3, []string{"a", "b", "c"}}, // narrower than a character
+		{"abc", 0, []string{"a", "b", "c"}},
+	} {
+		if got := ili948x.WrapText(tt.s, font, tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WrapText(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestMeasureText(t *testing.T) {
+	font := ili948x.Font7x13
+	for _, tt := range []struct {
+		s             string
+		width, height int16
+	}{
+		{"", 0, 13},
+		{"hello", 35, 13},
+		{"ab\nabcd\n", 28, 39},
+		{"\n\n", 0, 39},
+		{"héllo", 35, 13}, // runes, not bytes
+	} {
+		w, h := ili948x.MeasureText(tt.s, font)
+		if w != tt.width || h != tt.height {
+			t.Errorf("MeasureText(%q) = %d, %d, want %d, %d", tt.s, w, h, tt.width, tt.height)
+		}
+	}
+}
+
+// aaFont returns a font of bpp bits per pixel with a 4x2 glyph for 'A',
+// its coverage levels given as fractions of 15.
+func aaFont(bpp int) (*ili948x.Font, []int) {
+	levels := []int{0, 3, 8, 15, 15, 12, 5, 1}
+	max := 1<<bpp - 1
+	var bitmap []uint8
+	var acc, n int
+	values := make([]int, len(levels))
+	for i, l := range levels {
+		values[i] = (l*max + 7) / 15
+		acc = acc<<bpp | values[i]
+		if n += bpp; n == 8 {
+			bitmap = append(bitmap, uint8(acc))
+			acc, n = 0, 0
+		}
+	}
+	if n > 0 {
+		bitmap = append(bitmap, uint8(acc<<(8-n)))
+	}
+	for i := range values {
+		values[i] = values[i] * 255 / max
+	}
+	return &ili948x.Font{
+		Height: 3,
+		Ascent: 2,
+		BPP:    uint8(bpp),
+		Ranges: []ili948x.FontRange{{First: 'A', Last: 'A'}},
+		Glyphs: []ili948x.Glyph{{Width: 4, Height: 2, YOffset: -2, Advance: 5}},
+		Bitmap: bitmap,
+	}, values
+}
+
+// mix blends colors fg and bg by alpha a, 0 to 255.
+func mix(fg, bg uint32, a int) uint32 {
+	var c uint32
+	for shift := 0; shift < 24; shift += 8 {
+		f, b := int(fg>>shift&0xff), int(bg>>shift&0xff)
+		c |= uint32((f*a+b*(255-a)+127)/255) << shift
+	}
+	return c
+}
+
+func TestDrawTextOver(t *testing.T) {
+	const x, y, fg = 10, 20, 0xffe0a0
+	for _, bpp := range []int{2, 4, 8} {
+		disp, _ := newSimulated(t)
+		font, alpha := aaFont(bpp)
+
+		// a background of two colors, the second glyph over both
+		if err := disp.FillRectangle(x, y, 7, 3, 0x204060); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.FillRectangle(x+7, y, 3, 3, 0xc08040); err != nil {
+			t.Fatal(err)
+		}
+		before := make([]uint32, 10*3)
+		if err := disp.ReadRectangle(x, y, 10, 3, before); err != nil {
+			t.Fatal(err)
+		}
+		if err := disp.DrawTextOver(x, y, "AA", font, fg); err != nil {
+			t.Fatal(err)
+		}
+		got := make([]uint32, 10*3)
+		if err := disp.ReadRectangle(x, y, 10, 3, got); err != nil {
+			t.Fatal(err)
+		}
+
+		want := append([]uint32(nil), before...)
+		for _, pen := range []int{0, 5} {
+			for i, a := range alpha {
+				p := (i/4)*10 + pen + i%4
+				want[p] = mix(fg, before[p], a)
+			}
+		}
+		// the blended colors as the frame memory holds them
+		for i, c := range want {
+			if err := disp.FillRectangle(0, 0, 1, 1, c); err != nil {
+				t.Fatal(err)
+			}
+			if err := disp.ReadRectangle(0, 0, 1, 1, want[i:i+1]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%d bpp: pixel (%d, %d) = %#06x, want %#06x", bpp, i%10, i/10, got[i], want[i])
+			}
+		}
+	}
+}
+
+// screen is the frame memory of a display as read back, in row order.
+type screen []uint32
+
+// readScreen reads back the whole frame memory.
+func readScreen(t *testing.T, disp *ili948x.Ili948x) screen {
+	t.Helper()
+	s := make(screen, sim.Width*sim.Height)
+	if err := disp.ReadRectangle(0, 0, sim.Width, sim.Height, s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// text draws a line of a 1 bit font into s as DrawTextBox does: the box at
+// x, y is filled with bg and the glyphs, the pen starting offs right of x,
+// are clipped to the box and the screen.
+func (s screen) text(x, y, width, height int, line string, offs int, font *ili948x.Font, fg, bg uint32) {
+	set := func(px, py int, c uint32) {
+		if px >= x && px < x+width && py >= y && py < y+height &&
+			px >= 0 && px < sim.Width && py >= 0 && py < sim.Height {
+			s[py*sim.Width+px] = c
+		}
+	}
+	for py := y; py < y+height; py++ {
+		for px := x; px < x+width; px++ {
+			set(px, py, bg)
+		}
+	}
+	pen := x + offs
+	for _, r := range line {
+		g := font.Glyph(r)
+		for gy := 0; gy < int(g.Height); gy++ {
+			for gx := 0; gx < int(g.Width); gx++ {
+				i := int(g.Offset)*8 + gy*int(g.Width) + gx
+				if font.Bitmap[i/8]&(0x80>>(i%8)) != 0 {
+					set(pen+int(g.XOffset)+gx, y+int(font.Ascent)+int(g.YOffset)+gy, fg)
+				}
+			}
+		}
+		pen += int(g.Advance)
+	}
+}
+
+// check compares the frame memory with s.
+func (s screen) check(t *testing.T, name string, got screen) {
+	t.Helper()
+	for i := range s {
+		if got[i] != s[i] {
+			t.Errorf("%s: pixel (%d, %d) = %#06x, want %#06x", name, i%sim.Width, i/sim.Width, got[i], s[i])
+			return
+		}
+	}
+}
+
+// filledScreen returns a display filled with color and its frame memory.
+func filledScreen(t *testing.T, color uint32) (*ili948x.Ili948x, screen) {
+	t.Helper()
+	disp, _ := newSimulated(t)
+	if err := disp.FillScreen(color); err != nil {
+		t.Fatal(err)
+	}
+	return disp, readScreen(t, disp)
+}
+
+func TestDrawText(t *testing.T) {
+	const fg, bg, red = 0xfcfcfc, 0x0000fc, 0xfc0000 // exact in the 18 bit frame memory
+	font := ili948x.Font7x13
+	disp, want := filledScreen(t, red)
+	for _, tt := range []struct {
+		name  string
+		x, y  int
+		lines []string
+	}{
+		{"lines", 10, 20, []string{"Ag|", "", "jq{}"}},
+		{"top left", -3, -5, []string{"clipped", "text"}},
+		{"bottom right", 310, 470, []string{"clipped", "text"}},
+		{"off the screen", 320, 100, []string{"none"}},
+	} {
+		s := strings.Join(tt.lines, "\n")
+		if err := disp.DrawText(int16(tt.x), int16(tt.y), s, font, fg, bg); err != nil {
+			t.Fatal(err)
+		}
+		for i, line := range tt.lines {
+			w, _ := ili948x.MeasureText(line, font)
+			want.text(tt.x, tt.y+i*13, int(w), 13, line, 0, font, fg, bg)
+		}
+		want.check(t, tt.name, readScreen(t, disp))
+	}
+}
+
+func TestDrawTextBox(t *testing.T) {
+	const fg, bg, red = 0xfcfcfc, 0x0000fc, 0xfc0000 // exact in the 18 bit frame memory
+	font := ili948x.Font7x13
+	const text = "one two three four\nfive"
+	for _, tt := range []struct {
+		name                string
+		x, y, width, height int
+		align               ili948x.Align
+	}{
+		{"left", 10, 20, 100, 120, ili948x.AlignLeft},
+		{"center", 10, 20, 100, 120, ili948x.AlignCenter},
+		{"right", 10, 20, 100, 120, ili948x.AlignRight},
+		{"cut last line", 10, 20, 100, 30, ili948x.AlignCenter}, // 2 lines and 4 rows
+		{"clipped", 280, 460, 60, 40, ili948x.AlignRight},
+		{"narrow", 10, 20, 16, 70, ili948x.AlignLeft},
+	} {
+		disp, want := filledScreen(t, red)
+		if err := disp.DrawTextBox(int16(tt.x), int16(tt.y), int16(tt.width), int16(tt.height),
+			text, font, fg, bg, tt.align); err != nil {
+			t.Fatal(err)
+		}
+
+		// the box is filled below the last line
+		want.text(tt.x, tt.y, tt.width, tt.height, "", 0, font, fg, bg)
+		y := tt.y
+		for _, line := range ili948x.WrapText(text, font, tt.width) {
+			rows := 13
+			if rows > tt.y+tt.height-y {
+				rows = tt.y + tt.height - y
+			}
+			if rows <= 0 {
+				break
+			}
+			w, _ := ili948x.MeasureText(line, font)
+			offs := 0
+			switch tt.align {
+			case ili948x.AlignCenter:
+				offs = (tt.width - int(w)) / 2
+			case ili948x.AlignRight:
+				offs = tt.width - int(w)
+			}
+			want.text(tt.x, y, tt.width, rows, line, offs, font, fg, bg)
+			y += rows
+		}
+		want.check(t, tt.name, readScreen(t, disp))
+	}
+}
+
+func TestDrawTextWindows(t *testing.T) {
+	font := ili948x.Font7x13
+	for _, tt := range []struct {
+		name string
+		draw func(disp *ili948x.Ili948x) error
+		wins []image.Rectangle
+	}{
+		{
+			"DrawText",
+			func(disp *ili948x.Ili948x) error { return disp.DrawText(10, 20, "ab\nc\nde", font, 0xffffff, 0) },
+			[]image.Rectangle{image.Rect(10, 20, 24, 33), image.Rect(10, 33, 17, 46), image.Rect(10, 46, 24, 59)},
+		},
+		{
+			"DrawText clipped",
+			func(disp *ili948x.Ili948x) error { return disp.DrawText(-3, 470, "ab\nc", font, 0xffffff, 0) },
+			[]image.Rectangle{image.Rect(0, 470, 11, 480)},
+		},
+		{
+			// two lines, the second cut, and the fill below
+			"DrawTextBox",
+			func(disp *ili948x.Ili948x) error {
+				return disp.DrawTextBox(10, 20, 50, 20, "ab\ncd", font, 0xffffff, 0, ili948x.AlignCenter)
+			},
+			[]image.Rectangle{image.Rect(10, 20, 60, 33), image.Rect(10, 33, 60, 40)},
+		},
+		{
+			"DrawTextBox fill",
+			func(disp *ili948x.Ili948x) error {
+				return disp.DrawTextBox(10, 20, 50, 40, "ab", font, 0xffffff, 0, ili948x.AlignLeft)
+			},
+			[]image.Rectangle{image.Rect(10, 20, 60, 33), image.Rect(10, 33, 60, 60)},
+		},
+	} {
+		disp, rec := newRecorded(t)
+		if err := tt.draw(disp); err != nil {
+			t.Fatal(err)
+		}
+		var wins []image.Rectangle
+		var win image.Rectangle
+		for _, op := range rec.Ops() {
+			switch op.Cmd {
+			case ili948x.CMD_CASET:
+				win.Min.X, win.Max.X = be16(op.Params), be16(op.Params[2:])+1
+			case ili948x.CMD_PASET:
+				win.Min.Y, win.Max.Y = be16(op.Params), be16(op.Params[2:])+1
+			case ili948x.CMD_RAMWR:
+				if len(op.Params) != 3*win.Dx()*win.Dy() {
+					t.Errorf("%s: window %v written with %d bytes", tt.name, win, len(op.Params))
+				}
+				wins = append(wins, win)
+			default:
+				t.Errorf("%s: unexpected command %#02x", tt.name, op.Cmd)
+			}
+		}
+		if !reflect.DeepEqual(wins, tt.wins) {
+			t.Errorf("%s: got windows %v, want %v", tt.name, wins, tt.wins)
+		}
+	}
+}
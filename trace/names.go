@@ -0,0 +1,106 @@
+package trace
+
+import (
+	"github.com/inindev/ili948x"
+)
+
+// cmdNames maps command opcodes to their registers.go names.
+var cmdNames = map[uint8]string{
+	ili948x.CMD_NOP:        "CMD_NOP",
+	ili948x.CMD_SWRESET:    "CMD_SWRESET",
+	ili948x.CMD_RDDIDIF:    "CMD_RDDIDIF",
+	ili948x.CMD_RDNUMED:    "CMD_RDNUMED",
+	ili948x.CMD_RDDST:      "CMD_RDDST",
+	ili948x.CMD_RDDPM:      "CMD_RDDPM",
+	ili948x.CMD_RDDMADCTRL: "CMD_RDDMADCTRL",
+	ili948x.CMD_RDDCOLMOD:  "CMD_RDDCOLMOD",
+	ili948x.CMD_RDDIM:      "CMD_RDDIM",
+	ili948x.CMD_RDDSM:      "CMD_RDDSM",
+	ili948x.CMD_RDDSDR:     "CMD_RDDSDR",
+	ili948x.CMD_SLPIN:      "CMD_SLPIN",
+	ili948x.CMD_SLPOUT:     "CMD_SLPOUT",
+	ili948x.CMD_PTLON:      "CMD_PTLON",
+	ili948x.CMD_NORON:      "CMD_NORON",
+	ili948x.CMD_INVOFF:     "CMD_INVOFF",
+	ili948x.CMD_INVON:      "CMD_INVON",
+	ili948x.CMD_ALLPOFF:    "CMD_ALLPOFF",
+	ili948x.CMD_ALLPON:     "CMD_ALLPON",
+	ili948x.CMD_DISOFF:     "CMD_DISOFF",
+	ili948x.CMD_DISON:      "CMD_DISON",
+	ili948x.CMD_CASET:      "CMD_CASET",
+	ili948x.CMD_PASET:      "CMD_PASET",
+	ili948x.CMD_RAMWR:      "CMD_RAMWR",
+	ili948x.CMD_RAMRD:      "CMD_RAMRD",
+	ili948x.CMD_PLTAR:      "CMD_PLTAR",
+	ili948x.CMD_VSCRDEF:    "CMD_VSCRDEF",
+	ili948x.CMD_TEOFF:      "CMD_TEOFF",
+	ili948x.CMD_TEON:       "CMD_TEON",
+	ili948x.CMD_MADCTRL:    "CMD_MADCTRL",
+	ili948x.CMD_VSCRSADD:   "CMD_VSCRSADD",
+	ili948x.CMD_IDMOFF:     "CMD_IDMOFF",
+	ili948x.CMD_IDMON:      "CMD_IDMON",
+	ili948x.CMD_PIXFMT:     "CMD_PIXFMT",
+	ili948x.CMD_RAMWRC:     "CMD_RAMWRC",
+	ili948x.CMD_RAMRDRC:    "CMD_RAMRDRC",
+	ili948x.CMD_TESLWR:     "CMD_TESLWR",
+	ili948x.CMD_TESLRD:     "CMD_TESLRD",
+	ili948x.CMD_WRDISBV:    "CMD_WRDISBV",
+	ili948x.CMD_RDDISBV:    "CMD_RDDISBV",
+	ili948x.CMD_WRCTRLD:    "CMD_WRCTRLD",
+	ili948x.CMD_RDCTRLD:    "CMD_RDCTRLD",
+	ili948x.CMD_WRCABC:     "CMD_WRCABC",
+	ili948x.CMD_RDCABC:     "CMD_RDCABC",
+	ili948x.CMD_WRCABCMB:   "CMD_WRCABCMB",
+	ili948x.CMD_RDCABCMB:   "CMD_RDCABCMB",
+	ili948x.CMD_RDABCSDR:   "CMD_RDABCSDR",
+	ili948x.CMD_IFMODE:     "CMD_IFMODE",
+	ili948x.CMD_FRMCTRL1:   "CMD_FRMCTRL1",
+	ili948x.CMD_FRMCTRL2:   "CMD_FRMCTRL2",
+	ili948x.CMD_FRMCTRL3:   "CMD_FRMCTRL3",
+	ili948x.CMD_INVCTRL:    "CMD_INVCTRL",
+	ili948x.CMD_PRCTRL:     "CMD_PRCTRL",
+	ili948x.CMD_DISCTRL:    "CMD_DISCTRL",
+	ili948x.CMD_ETMOD:      "CMD_ETMOD",
+	ili948x.CMD_CECTRL1:    "CMD_CECTRL1",
+	ili948x.CMD_CECTRL2:    "CMD_CECTRL2",
+	ili948x.CMD_HSLCTRL:    "CMD_HSLCTRL",
+	ili948x.CMD_PWCTRL1:    "CMD_PWCTRL1",
+	ili948x.CMD_PWCTRL2:    "CMD_PWCTRL2",
+	ili948x.CMD_PWCTRL3:    "CMD_PWCTRL3",
+	ili948x.CMD_PWCTRL4:    "CMD_PWCTRL4",
+	ili948x.CMD_PWCTRL5:    "CMD_PWCTRL5",
+	ili948x.CMD_VMCTRL:     "CMD_VMCTRL",
+	ili948x.CMD_CABCCTRL1:  "CMD_CABCCTRL1",
+	ili948x.CMD_CABCCTRL2:  "CMD_CABCCTRL2",
+	ili948x.CMD_CABCCTRL3:  "CMD_CABCCTRL3",
+	ili948x.CMD_CABCCTRL4:  "CMD_CABCCTRL4",
+	ili948x.CMD_CABCCTRL5:  "CMD_CABCCTRL5",
+	ili948x.CMD_CABCCTRL6:  "CMD_CABCCTRL6",
+	ili948x.CMD_CABCCTRL7:  "CMD_CABCCTRL7",
+	ili948x.CMD_CABCCTRL8:  "CMD_CABCCTRL8",
+	ili948x.CMD_CABCCTRL9:  "CMD_CABCCTRL9",
+	ili948x.CMD_NVMWR:      "CMD_NVMWR",
+	ili948x.CMD_NVMPKEY:    "CMD_NVMPKEY",
+	ili948x.CMD_NVMSRD:     "CMD_NVMSRD",
+	ili948x.CMD_RDID4:      "CMD_RDID4",
+	ili948x.CMD_ADJCTRL1:   "CMD_ADJCTRL1",
+	ili948x.CMD_PGAMCTRL:   "CMD_PGAMCTRL",
+	ili948x.CMD_NGAMCTRL:   "CMD_NGAMCTRL",
+	ili948x.CMD_DGAMCTRL1:  "CMD_DGAMCTRL1",
+	ili948x.CMD_DGAMCTRL2:  "CMD_DGAMCTRL2",
+	ili948x.CMD_SETIMAGE:   "CMD_SETIMAGE",
+	ili948x.CMD_ADJCTRL2:   "CMD_ADJCTRL2",
+	ili948x.CMD_ADJCTRL3:   "CMD_ADJCTRL3",
+	ili948x.CMD_ADJCTRL4:   "CMD_ADJCTRL4",
+	ili948x.CMD_ADJCTRL5:   "CMD_ADJCTRL5",
+	ili948x.CMD_SPIRDCMDS:  "CMD_SPIRDCMDS",
+	ili948x.CMD_ADJCTRL6:   "CMD_ADJCTRL6",
+}
+
+// CommandName returns the CMD_* name of a command opcode, or its hex value if unknown.
+func CommandName(cmd uint8) string {
+	if name, ok := cmdNames[cmd]; ok {
+		return name
+	}
+	return "CMD_" + hex(cmd)
+}
@@ -0,0 +1,235 @@
+// Package trace provides a recording ili948x.Transport which captures the
+// exact command and data stream sent to the display, for use in host tests.
+package trace
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/inindev/ili948x"
+)
+
+var _ ili948x.ReadTransport = (*Recorder)(nil)
+
+// maxParams is the number of parameter bytes shown per command by String.
+const maxParams = 16
+
+// Write is a single transport call as seen on the bus.
+type Write struct {
+	DC   bool    // data / command pin level: true = data
+	Bits int     // transfer width: 8, 16 or 24
+	Data []uint8 // bytes in wire order
+	Read bool    // Data was read from the display
+}
+
+// Op is a command byte followed by its parameter bytes.
+type Op struct {
+	Cmd    uint8
+	Params []uint8
+}
+
+// Recorder is an ili948x.Transport which records every write together with
+// the level of the data / command pin. Bytes are recorded in the same order
+// the SPI transport puts them on the wire (least significant byte first).
+type Recorder struct {
+	dc       bool
+	writes   []Write
+	err      error   // returned by writes instead of recording
+	response []uint8 // bytes returned by subsequent reads
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{dc: true}
+}
+
+// DC returns the data / command pin to pass to the display constructor.
+func (rec *Recorder) DC() ili948x.Pin {
+	return (*dcPin)(rec)
+}
+
+// Writes returns the raw transport calls recorded so far.
+func (rec *Recorder) Writes() []Write {
+	return rec.writes
+}
+
+// Ops decodes the recorded writes into commands with their parameters.
+// Data written before the first command is not part of any op.
+func (rec *Recorder) Ops() []Op {
+	var ops []Op
+	for _, w := range rec.writes {
+		if w.Read {
+			continue
+		}
+		if w.DC {
+			if len(ops) > 0 {
+				op := &ops[len(ops)-1]
+				op.Params = append(op.Params, w.Data...)
+			}
+			continue
+		}
+		for _, b := range w.Data {
+			ops = append(ops, Op{Cmd: b})
+		}
+	}
+	return ops
+}
+
+// Commands returns the opcodes of the recorded ops in order.
+func (rec *Recorder) Commands() []uint8 {
+	ops := rec.Ops()
+	cmds := make([]uint8, len(ops))
+	for i, op := range ops {
+		cmds[i] = op.Cmd
+	}
+	return cmds
+}
+
+// SetError makes subsequent writes fail with err without being recorded,
+// simulating a bus failure. A nil err restores normal operation.
+func (rec *Recorder) SetError(err error) {
+	rec.err = err
+}
+
+// Respond queues bytes to be returned by subsequent reads, including the
+// dummy byte the display sends first. Reads beyond the queue return zeros.
+func (rec *Recorder) Respond(data ...uint8) {
+	rec.response = append(rec.response, data...)
+}
+
+// Reset discards everything recorded so far.
+func (rec *Recorder) Reset() {
+	rec.writes = nil
+}
+
+// String returns the recorded ops, one per line.
+func (rec *Recorder) String() string {
+	var sb strings.Builder
+	for _, op := range rec.Ops() {
+		sb.WriteString(op.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// String returns the op as its command name followed by its parameter bytes
+// in hex, eliding long parameter lists such as pixel data.
+func (op Op) String() string {
+	var sb strings.Builder
+	sb.WriteString(CommandName(op.Cmd))
+	for i, b := range op.Params {
+		if i == maxParams {
+			sb.WriteString(" ... (")
+			sb.WriteString(strconv.Itoa(len(op.Params)))
+			sb.WriteString(" bytes)")
+			break
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(hex(b))
+	}
+	return sb.String()
+}
+
+// 8 bit
+func (rec *Recorder) Write8(data uint8) error {
+	return rec.record(8, []uint8{data})
+}
+
+func (rec *Recorder) Write8n(data uint8, n int) error {
+	buf := make([]uint8, 0, n)
+	for i := 0; i < n; i++ {
+		buf = append(buf, data)
+	}
+	return rec.record(8, buf)
+}
+
+func (rec *Recorder) Write8sl(data []uint8) error {
+	return rec.record(8, append([]uint8(nil), data...))
+}
+
+// 16 bit
+func (rec *Recorder) Write16(data uint16) error {
+	return rec.Write16sl([]uint16{data})
+}
+
+func (rec *Recorder) Write16n(data uint16, n int) error {
+	buf := make([]uint8, 0, n*2)
+	for i := 0; i < n; i++ {
+		buf = append(buf, uint8(data), uint8(data>>8))
+	}
+	return rec.record(16, buf)
+}
+
+func (rec *Recorder) Write16sl(data []uint16) error {
+	buf := make([]uint8, 0, len(data)*2)
+	for _, d := range data {
+		buf = append(buf, uint8(d), uint8(d>>8))
+	}
+	return rec.record(16, buf)
+}
+
+// 24 bit
+func (rec *Recorder) Write24(data uint32) error {
+	return rec.Write24sl([]uint32{data})
+}
+
+func (rec *Recorder) Write24n(data uint32, n int) error {
+	buf := make([]uint8, 0, n*3)
+	for i := 0; i < n; i++ {
+		buf = append(buf, uint8(data), uint8(data>>8), uint8(data>>16))
+	}
+	return rec.record(24, buf)
+}
+
+func (rec *Recorder) Write24sl(data []uint32) error {
+	buf := make([]uint8, 0, len(data)*3)
+	for _, d := range data {
+		buf = append(buf, uint8(d), uint8(d>>8), uint8(d>>16))
+	}
+	return rec.record(24, buf)
+}
+
+func (rec *Recorder) Read8sl(data []uint8) error {
+	if rec.err != nil {
+		return rec.err
+	}
+	n := copy(data, rec.response)
+	rec.response = rec.response[n:]
+	for i := n; i < len(data); i++ {
+		data[i] = 0
+	}
+	rec.writes = append(rec.writes, Write{DC: rec.dc, Bits: 8, Data: append([]uint8(nil), data...), Read: true})
+	return nil
+}
+
+func (rec *Recorder) SetReadMode(read bool) error {
+	return rec.err
+}
+
+func (rec *Recorder) record(bits int, data []uint8) error {
+	if rec.err != nil {
+		return rec.err
+	}
+	rec.writes = append(rec.writes, Write{DC: rec.dc, Bits: bits, Data: data})
+	return nil
+}
+
+// dcPin tracks the data / command pin level for its Recorder.
+type dcPin Recorder
+
+func (p *dcPin) Set(high bool) {
+	p.dc = high
+}
+
+func (p *dcPin) High() {
+	p.dc = true
+}
+
+func (p *dcPin) Low() {
+	p.dc = false
+}
+
+func hex(b uint8) string {
+	const digits = "0123456789abcdef"
+	return string([]byte{digits[b>>4], digits[b&0x0f]})
+}
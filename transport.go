@@ -0,0 +1,32 @@
+package ili948x
+
+// Transport carries command and pixel data to the display controller.
+// Multi-byte values are sent least significant byte first.
+type Transport interface {
+	// 8 bit
+	Write8(b uint8) error
+	Write8n(b uint8, n int) error
+	Write8sl(b []uint8) error
+
+	// 16 bit
+	Write16(data uint16) error
+	Write16n(data uint16, n int) error
+	Write16sl(data []uint16) error
+
+	// 24 bit
+	Write24(data uint32) error
+	Write24n(data uint32, n int) error
+	Write24sl(data []uint32) error
+}
+
+// ReadTransport is implemented by transports which can also read from the
+// display controller, e.g. CMD_RAMRD or the display ID.
+type ReadTransport interface {
+	Transport
+
+	// Read8sl reads len(b) bytes from the display.
+	Read8sl(b []uint8) error
+
+	// SetReadMode switches the bus to its (slower) read configuration and back.
+	SetReadMode(read bool) error
+}